@@ -0,0 +1,50 @@
+package exoscale
+
+import (
+	"sync"
+)
+
+// lookupCache memoizes the catalogue lookups (zones, offerings, templates)
+// for the length of a run. Concurrent lookups of the same key share a single
+// API request, failed lookups are forgotten so that they can be tried again.
+type lookupCache struct {
+	mu      sync.Mutex
+	entries map[string]*lookupEntry
+}
+
+type lookupEntry struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+func newLookupCache() *lookupCache {
+	return &lookupCache{
+		entries: make(map[string]*lookupEntry),
+	}
+}
+
+// get returns the value stored under key, calling fetch to obtain it the
+// first time.
+func (c *lookupCache) get(key string, fetch func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		<-entry.done
+		return entry.value, entry.err
+	}
+
+	entry := &lookupEntry{done: make(chan struct{})}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	entry.value, entry.err = fetch()
+	if entry.err != nil {
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
+	}
+	close(entry.done)
+
+	return entry.value, entry.err
+}
@@ -0,0 +1,141 @@
+package exoscale
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/exoscale/egoscale"
+)
+
+func TestLookupCacheConcurrency(t *testing.T) {
+	cache := newLookupCache()
+	release := make(chan struct{})
+
+	var mu sync.Mutex
+	fetched := 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := cache.get("key", func() (interface{}, error) {
+				mu.Lock()
+				fetched++
+				mu.Unlock()
+
+				<-release
+				return "value", nil
+			})
+			if err != nil || v.(string) != "value" {
+				t.Errorf("unexpected result: %v, %v", v, err)
+			}
+		}()
+	}
+	close(release)
+	wg.Wait()
+
+	if fetched != 1 {
+		t.Errorf("expected a single fetch, got %d", fetched)
+	}
+}
+
+func TestLookupCacheError(t *testing.T) {
+	cache := newLookupCache()
+
+	if _, err := cache.get("key", func() (interface{}, error) {
+		return nil, errors.New("failure")
+	}); err == nil {
+		t.Fatal("expected an error, got none")
+	}
+
+	v, err := cache.get("key", func() (interface{}, error) {
+		return "value", nil
+	})
+	if err != nil || v.(string) != "value" {
+		t.Errorf("failed lookups shouldn't be cached, got: %v, %v", v, err)
+	}
+}
+
+func TestCatalogueLookupsCached(t *testing.T) {
+	api, config := newMockComputeConfig(1)
+	defer api.Close()
+
+	client, err := GetComputeClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		zone, err := getZoneByName(ctx, client, defaultExoscaleZone)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := getNetworkOfferingByName(ctx, client, defaultExoscaleNetworkOffering); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := getServiceOfferingsByName(ctx, client, "Micro"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := getTemplates(ctx, client, egoscale.ListTemplates{
+			TemplateFilter: "featured",
+			ZoneID:         zone.ID,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := getISO(ctx, client, zone.ID, testAccISO, "executable"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, command := range []string{"listZones", "listNetworkOfferings", "listServiceOfferings", "listTemplates", "listIsos"} {
+		if n := api.callCount(command); n != 1 {
+			t.Errorf("expected %s to be called once, got %d", command, n)
+		}
+	}
+
+	// The cache is shared by all the users of the provider instance
+	shared, err := GetComputeClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getZoneByName(ctx, shared, defaultExoscaleZone); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := api.callCount("listZones"); n != 1 {
+		t.Errorf("expected listZones to be called once, got %d", n)
+	}
+}
+
+func TestCatalogueLookupsNotFound(t *testing.T) {
+	api, config := newMockComputeConfig(1)
+	defer api.Close()
+
+	client, err := GetComputeClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := getZoneByName(ctx, client, "xx-nowhere-1"); err == nil {
+			t.Fatal("expected an error, got none")
+		}
+	}
+
+	if n := api.callCount("listZones"); n != 2 {
+		t.Errorf("expected missing zones not to be cached, got %d calls", n)
+	}
+
+	if _, err := getZoneByName(ctx, client, ""); err == nil {
+		t.Error("expected an error for an empty zone, got none")
+	}
+}
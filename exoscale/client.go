@@ -1,6 +1,8 @@
 package exoscale
 
 import (
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/exoscale/egoscale"
@@ -13,26 +15,52 @@ const defaultComputeEndpoint = "https://api.exoscale.ch/compute"
 const defaultDNSEndpoint = "https://api.exoscale.ch/dns"
 const defaultTimeout = 5 * time.Minute
 const defaultGzipUserData = true
+const defaultRetryMaxAttempts = 3
+const defaultRetryBackoff = 2 * time.Second
+const defaultMaxConcurrentRequests = 8
+const defaultRequestsPerSecond = 0
 
-// BaseConfig represents the provider structure
+// BaseConfig represents the provider structure, it is shared by all the
+// resources of a provider instance
 type BaseConfig struct {
-	key             string
-	secret          string
-	timeout         time.Duration
-	computeEndpoint string
-	dnsEndpoint     string
-	gzipUserData    bool
-	computeClient   *egoscale.Client
-	dnsClient       *egoscale.Client
+	key                   string
+	secret                string
+	timeout               time.Duration
+	computeEndpoint       string
+	dnsEndpoint           string
+	gzipUserData          bool
+	retryMaxAttempts      int
+	retryBackoff          time.Duration
+	maxConcurrentRequests int
+	requestsPerSecond     float64
+	defaultTags           map[string]string
+	zone                  string
+	credentialProcess     string
+	expiration            time.Time
+
+	mu            sync.Mutex
+	renewMu       sync.Mutex
+	transport     http.RoundTripper
+	computeClient *computeClient
+	dnsClient     *dnsClient
 }
 
-func getClient(endpoint string, meta interface{}) *egoscale.Client {
-	config := meta.(BaseConfig)
+func getClient(endpoint string, config *BaseConfig) *egoscale.Client {
 	cs := egoscale.NewClient(endpoint, config.key, config.secret)
 
 	cs.Timeout = config.timeout
 	cs.HTTPClient.Timeout = config.timeout
 
+	// The transport, and its limits, is shared by the compute and DNS clients
+	if config.transport == nil {
+		config.transport = newLimitedTransport(
+			cs.HTTPClient.Transport,
+			config.maxConcurrentRequests,
+			config.requestsPerSecond,
+		)
+	}
+	cs.HTTPClient.Transport = config.transport
+
 	if logging.IsDebugOrHigher() {
 		cs.HTTPClient.Transport = logging.NewTransport(
 			"exoscale",
@@ -43,20 +71,60 @@ func getClient(endpoint string, meta interface{}) *egoscale.Client {
 	return cs
 }
 
-// GetComputeClient builds a CloudStack client
-func GetComputeClient(meta interface{}) *egoscale.Client {
-	config := meta.(BaseConfig)
+// newComputeClient builds the compute client retrying the requests, sharing
+// the catalogue lookups cache
+func newComputeClient(config *BaseConfig, cache *lookupCache) *computeClient {
+	return &computeClient{
+		Client:  getClient(config.computeEndpoint, config),
+		retrier: newRetrier(config),
+		cache:   cache,
+	}
+}
+
+// newRetrier builds the retry policy of the clients from the provider
+// configuration
+func newRetrier(config *BaseConfig) retrier {
+	return retrier{
+		maxAttempts:   config.retryMaxAttempts,
+		retryStrategy: exponentialRetryStrategy(config.retryBackoff),
+	}
+}
+
+// GetComputeClient returns the CloudStack client of the provider, it fails
+// when the credentials cannot be renewed
+func GetComputeClient(meta interface{}) (*computeClient, error) {
+	config := meta.(*BaseConfig)
+
+	if err := config.renewCredentials(); err != nil {
+		return nil, err
+	}
+
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
 	if config.computeClient == nil {
-		config.computeClient = getClient(config.computeEndpoint, meta)
+		config.computeClient = newComputeClient(config, newLookupCache())
 	}
-	return config.computeClient
+	return config.computeClient, nil
 }
 
-// GetDNSClient builds a DNS client
-func GetDNSClient(meta interface{}) *egoscale.Client {
-	config := meta.(BaseConfig)
+// GetDNSClient returns the DNS client of the provider, it fails when the
+// credentials cannot be renewed
+func GetDNSClient(meta interface{}) (*dnsClient, error) {
+	config := meta.(*BaseConfig)
+
+	if err := config.renewCredentials(); err != nil {
+		return nil, err
+	}
+
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
 	if config.dnsClient == nil {
-		config.dnsClient = getClient(config.dnsEndpoint, meta)
+		config.dnsClient = &dnsClient{
+			Client:  getClient(config.dnsEndpoint, config),
+			retrier: newRetrier(config),
+		}
 	}
-	return config.dnsClient
+	return config.dnsClient, nil
 }
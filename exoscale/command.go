@@ -12,3 +12,10 @@ type partialCommand struct {
 	partials []string
 	request  egoscale.Command
 }
+
+// resourceChanges tells which attributes of a resource are to be updated, and
+// from which values. *schema.ResourceData compares the state and the plan.
+type resourceChanges interface {
+	HasChange(key string) bool
+	GetChange(key string) (interface{}, interface{})
+}
@@ -0,0 +1,132 @@
+package exoscale
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// credentialsExpiryWindow is how long before their expiration the credentials
+// given by the credential_process are renewed
+const credentialsExpiryWindow = time.Minute
+
+// processCredentials represents the output of the credential_process
+type processCredentials struct {
+	Key        string     `json:"key"`
+	Secret     string     `json:"secret"`
+	Expiration *time.Time `json:"expiration,omitempty"`
+}
+
+// runCredentialProcess runs the command through the shell and reads the
+// credentials from its output
+func runCredentialProcess(command string) (*processCredentials, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd.exe", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credential_process failed: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	credentials := new(processCredentials)
+	if err := json.Unmarshal(out, credentials); err != nil {
+		return nil, fmt.Errorf("credential_process output is not valid: %s", err)
+	}
+
+	if credentials.Key == "" || credentials.Secret == "" {
+		return nil, fmt.Errorf("credential_process output is missing the key or the secret")
+	}
+
+	return credentials, nil
+}
+
+// setProcessCredentials runs the credential_process and stores its credentials
+func (config *BaseConfig) setProcessCredentials() error {
+	credentials, err := runCredentialProcess(config.credentialProcess)
+	if err != nil {
+		return err
+	}
+
+	config.setCredentials(credentials)
+
+	return nil
+}
+
+// setCredentials stores the credentials given by the credential_process
+func (config *BaseConfig) setCredentials(credentials *processCredentials) {
+	config.key = credentials.Key
+	config.secret = credentials.Secret
+	config.expiration = time.Time{}
+	if credentials.Expiration != nil {
+		config.expiration = *credentials.Expiration
+	}
+}
+
+// credentialsExpiring tells whether the credentials given by the
+// credential_process are about to expire. It expects config.mu to be held.
+func (config *BaseConfig) credentialsExpiring() bool {
+	if config.credentialProcess == "" || config.expiration.IsZero() {
+		return false
+	}
+
+	return !time.Now().Add(credentialsExpiryWindow).Before(config.expiration)
+}
+
+// renewCredentials runs the credential_process again when the credentials
+// are about to expire, the clients are then built anew. The process runs
+// without holding config.mu, the requests using the current clients aren't
+// blocked, only the concurrent renewals wait for it.
+func (config *BaseConfig) renewCredentials() error {
+	config.mu.Lock()
+	expiring := config.credentialsExpiring()
+	config.mu.Unlock()
+
+	if !expiring {
+		return nil
+	}
+
+	config.renewMu.Lock()
+	defer config.renewMu.Unlock()
+
+	// The credentials may have been renewed while waiting
+	config.mu.Lock()
+	expiring = config.credentialsExpiring()
+	expiration := config.expiration
+	command := config.credentialProcess
+	config.mu.Unlock()
+
+	if !expiring {
+		return nil
+	}
+
+	log.Printf("[DEBUG] credentials expire at %s, running the credential_process", expiration)
+	credentials, err := runCredentialProcess(command)
+	if err != nil {
+		return fmt.Errorf("unable to renew the credentials: %s", err)
+	}
+
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
+	config.setCredentials(credentials)
+
+	if config.computeClient != nil {
+		// The catalogue lookups remain valid
+		config.computeClient = newComputeClient(config, config.computeClient.cache)
+	}
+	config.dnsClient = nil
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package exoscale
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// testCredentialProcess writes a script printing new credentials on each run,
+// they expire after the given duration
+func testCredentialProcess(t *testing.T, expiresIn time.Duration) (string, func()) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test credential_process is a shell script")
+	}
+
+	dir, err := ioutil.TempDir("", "exoscale")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counter := filepath.Join(dir, "counter")
+	script := fmt.Sprintf(`n=$(($(cat %[1]s 2>/dev/null || echo 0) + 1))
+echo $n > %[1]s
+printf '{"key": "EXOkey%%s", "secret": "secret%%s", "expiration": "%[2]s"}' $n $n
+`, counter, time.Now().Add(expiresIn).UTC().Format(time.RFC3339))
+
+	filename := filepath.Join(dir, "credentials.sh")
+	if err := ioutil.WriteFile(filename, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	return filename, func() { os.RemoveAll(dir) } // nolint: errcheck
+}
+
+func TestRunCredentialProcess(t *testing.T) {
+	command, cleanup := testCredentialProcess(t, time.Hour)
+	defer cleanup()
+
+	credentials, err := runCredentialProcess(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if credentials.Key != "EXOkey1" || credentials.Secret != "secret1" {
+		t.Errorf("unexpected credentials: %#v", credentials)
+	}
+
+	if credentials.Expiration == nil || credentials.Expiration.Before(time.Now()) {
+		t.Errorf("expected an expiration in the future, got %v", credentials.Expiration)
+	}
+}
+
+func TestRunCredentialProcessErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test credential_process is a shell command")
+	}
+
+	for _, tc := range []struct {
+		command string
+		err     string
+	}{
+		{command: "echo oops >&2; exit 1", err: "oops"},
+		{command: "echo not json", err: "not valid"},
+		{command: `echo '{"key": "EXOkey"}'`, err: "missing the key or the secret"},
+	} {
+		_, err := runCredentialProcess(tc.command)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%q: expected error %q, got %v", tc.command, tc.err, err)
+		}
+	}
+}
+
+func TestCredentialProcessRenewal(t *testing.T) {
+	command, cleanup := testCredentialProcess(t, 30*time.Second)
+	defer cleanup()
+
+	config := &BaseConfig{
+		computeEndpoint:   defaultComputeEndpoint,
+		dnsEndpoint:       defaultDNSEndpoint,
+		timeout:           defaultTimeout,
+		retryMaxAttempts:  defaultRetryMaxAttempts,
+		credentialProcess: command,
+	}
+	if err := config.setProcessCredentials(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The credentials expire within the renewal window
+	client, err := GetComputeClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.APIKey != "EXOkey2" {
+		t.Errorf("expected the credentials to be renewed, got key %q", client.APIKey)
+	}
+
+	dns, err := GetDNSClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dns.APIKey != "EXOkey3" {
+		t.Errorf("expected the credentials to be renewed, got key %q", dns.APIKey)
+	}
+
+	renewed, err := GetComputeClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed == client || renewed.cache != client.cache {
+		t.Error("expected a new compute client sharing the lookup cache")
+	}
+
+	config.expiration = time.Now().Add(time.Hour)
+	kept, err := GetComputeClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept != renewed {
+		t.Error("expected the compute client to be kept until the credentials expire")
+	}
+}
+
+func TestCredentialProcessRenewalError(t *testing.T) {
+	command, cleanup := testCredentialProcess(t, 30*time.Second)
+	defer cleanup()
+
+	config := &BaseConfig{
+		computeEndpoint:   defaultComputeEndpoint,
+		dnsEndpoint:       defaultDNSEndpoint,
+		timeout:           defaultTimeout,
+		retryMaxAttempts:  defaultRetryMaxAttempts,
+		credentialProcess: command,
+	}
+	if err := config.setProcessCredentials(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The credential_process fails from now on
+	config.credentialProcess = "echo oops >&2; exit 1"
+
+	if _, err := GetComputeClient(config); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("expected the renewal error, got %v", err)
+	}
+	if _, err := GetDNSClient(config); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("expected the renewal error, got %v", err)
+	}
+
+	if config.key != "EXOkey1" {
+		t.Errorf("expected the previous credentials to be kept, got key %q", config.key)
+	}
+}
+
+func TestProviderConfigureCredentialProcess(t *testing.T) {
+	command, cleanup := testCredentialProcess(t, time.Hour)
+	defer cleanup()
+	defer testUnsetCredentialsEnv()()
+
+	d := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, map[string]interface{}{
+		"credential_process": command,
+	})
+	meta, err := providerConfigure(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := meta.(*BaseConfig)
+	if config.key != "EXOkey1" || config.secret != "secret1" || config.expiration.IsZero() {
+		t.Errorf("unexpected credentials: %q, %q, %s", config.key, config.secret, config.expiration)
+	}
+
+	// Explicit credentials take precedence
+	d = schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, map[string]interface{}{
+		"credential_process": command,
+		"key":                "EXOkey",
+		"secret":             "secret",
+	})
+	meta, err = providerConfigure(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config := meta.(*BaseConfig); config.key != "EXOkey" || config.credentialProcess != "" {
+		t.Errorf("expected the explicit credentials, got %q", config.key)
+	}
+}
@@ -16,8 +16,8 @@ func datasourceComputeTemplate() *schema.Resource {
 		Schema: map[string]*schema.Schema{
 			"zone": {
 				Type:        schema.TypeString,
-				Description: "Name of the zone",
-				Required:    true,
+				Description: "Name of the zone (by default: the provider zone)",
+				Optional:    true,
 			},
 			"name": {
 				Type:          schema.TypeString,
@@ -55,7 +55,10 @@ func datasourceComputeTemplateRead(d *schema.ResourceData, meta interface{}) err
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	zoneName := d.Get("zone").(string)
 	zone, err := getZoneByName(ctx, client, zoneName)
@@ -87,12 +90,11 @@ func datasourceComputeTemplateRead(d *schema.ResourceData, meta interface{}) err
 		}
 	}
 
-	resp, err := client.RequestWithContext(ctx, &req)
+	templates, err := getTemplates(ctx, client, req)
 	if err != nil {
 		return fmt.Errorf("templates list query failed: %s", err)
 	}
 
-	templates := resp.(*egoscale.ListTemplatesResponse).Template
 	if len(templates) == 0 {
 		return errors.New("template not found")
 	}
@@ -0,0 +1,142 @@
+package exoscale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/exoscale/egoscale"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+func datasourceISO() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"zone": {
+				Type:        schema.TypeString,
+				Description: "Name of the zone (by default: the provider zone)",
+				Optional:    true,
+			},
+			"name": {
+				Type:          schema.TypeString,
+				Description:   "Name of the ISO",
+				Optional:      true,
+				ConflictsWith: []string{"id"},
+			},
+			"id": {
+				Type:          schema.TypeString,
+				Description:   "ID of the ISO",
+				Optional:      true,
+				ConflictsWith: []string{"name"},
+			},
+			"filter": {
+				Type:        schema.TypeString,
+				Description: "ISO filter to apply",
+				ValidateFunc: validation.StringMatch(regexp.MustCompile("(?:featured|community|mine)"),
+					`must be either "featured", "community" or "mine"`),
+				Optional: true,
+				Default:  "featured",
+			},
+
+			"bootable": {
+				Type:        schema.TypeBool,
+				Description: "Whether a Compute instance can boot from the ISO",
+				Computed:    true,
+			},
+		},
+
+		Read: datasourceISORead,
+	}
+}
+
+func datasourceISORead(d *schema.ResourceData, meta interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
+
+	zone, err := getZoneByName(ctx, client, d.Get("zone").(string))
+	if err != nil {
+		return err
+	}
+
+	// ISO filter "mine" is a friendlier alias for "self"
+	filter := d.Get("filter").(string)
+	if filter == "mine" {
+		filter = "self"
+	}
+
+	iso := d.Get("name").(string)
+	if id, ok := d.GetOk("id"); ok {
+		if _, err := egoscale.ParseUUID(id.(string)); err != nil {
+			return fmt.Errorf("invalid value for id: %s", err)
+		}
+		iso = id.(string)
+	}
+	if iso == "" {
+		return errors.New("either name or id must be specified")
+	}
+
+	found, err := getISO(ctx, client, zone.ID, iso, filter)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(found.ID.String())
+
+	if err := d.Set("id", d.Id()); err != nil {
+		return err
+	}
+	if err := d.Set("name", found.Name); err != nil {
+		return err
+	}
+	if err := d.Set("bootable", found.Bootable); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// getISO finds the ISO of the zone, by ID or by name
+func getISO(ctx context.Context, client *computeClient, zoneID *egoscale.UUID, iso, filter string) (*egoscale.ISO, error) {
+	req := &egoscale.ListISOs{
+		ZoneID:    zoneID,
+		IsoFilter: filter,
+	}
+
+	id, err := egoscale.ParseUUID(iso)
+	if err == nil {
+		req.ID = id
+	} else {
+		req.Name = iso
+	}
+
+	key := fmt.Sprintf("iso/%s/%s/%s/%s", req.IsoFilter, req.ZoneID, req.ID, req.Name)
+	isos, err := client.cache.get(key, func() (interface{}, error) {
+		resp, err := client.RequestWithContext(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return resp.(*egoscale.ListISOsResponse).ISO, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range isos.([]egoscale.ISO) {
+		if id != nil || strings.EqualFold(item.Name, iso) {
+			found := item
+			return &found, nil
+		}
+	}
+
+	return nil, fmt.Errorf("ISO %q not found", iso)
+}
@@ -0,0 +1,79 @@
+package exoscale
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccDatasourceISO(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "exoscale_iso" "rescue" {
+  zone = %q
+}`, defaultExoscaleZone),
+				ExpectError: regexp.MustCompile("either name or id must be specified"),
+			},
+			{
+				Config: fmt.Sprintf(`
+data "exoscale_iso" "rescue" {
+  zone = %q
+  name = %q
+}`, defaultExoscaleZone, testAccISO),
+				Check: resource.ComposeTestCheckFunc(
+					testAccDatasourceISOAttributes(testAttrs{
+						"id":       ValidateUUID(),
+						"name":     ValidateString(testAccISO),
+						"bootable": ValidateString("true"),
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+data "exoscale_iso" "rescue" {
+  zone = %q
+  name = %q
+}
+
+data "exoscale_iso" "by_id" {
+  zone = %q
+  id   = "${data.exoscale_iso.rescue.id}"
+}`, defaultExoscaleZone, testAccISO, defaultExoscaleZone),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.exoscale_iso.by_id", "id", "data.exoscale_iso.rescue", "id"),
+					resource.TestCheckResourceAttr("data.exoscale_iso.by_id", "name", testAccISO),
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+data "exoscale_iso" "rescue" {
+  zone = %q
+  name = "terraform-test-missing"
+}`, defaultExoscaleZone),
+				ExpectError: regexp.MustCompile(`ISO "terraform-test-missing" not found`),
+			},
+		},
+	})
+}
+
+func testAccDatasourceISOAttributes(expected testAttrs) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "exoscale_iso" {
+				continue
+			}
+
+			return checkResourceAttributes(expected, rs.Primary.Attributes)
+		}
+
+		return errors.New("iso datasource not found in the state")
+	}
+}
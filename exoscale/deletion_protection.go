@@ -0,0 +1,84 @@
+package exoscale
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// addDeletionProtection adds the deletion_protection flag to the schema
+func addDeletionProtection(s map[string]*schema.Schema) {
+	s["deletion_protection"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     false,
+		Description: "Refuse to delete or replace the resource, it must be disabled by a separate apply first",
+	}
+}
+
+// checkDeletionProtection refuses to delete the resource while its deletion
+// protection is enabled
+func checkDeletionProtection(d *schema.ResourceData, name string) error {
+	if d.Get("deletion_protection").(bool) {
+		return fmt.Errorf("%s: deletion protection is enabled, set deletion_protection to false and apply it before deleting the resource", resourceIDString(d, name))
+	}
+
+	return nil
+}
+
+// checkDeletionProtectionReplace refuses the plan changing the key, which
+// replaces the resource, while its deletion protection is enabled. The
+// protection is checked in the state, a plan disabling it is refused too.
+func checkDeletionProtectionReplace(d *schema.ResourceDiff, key string) error {
+	if d.Id() == "" || !d.HasChange(key) {
+		return nil
+	}
+
+	if protected, _ := d.GetChange("deletion_protection"); protected.(bool) {
+		return fmt.Errorf("deletion protection is enabled, changing %q would replace the resource. Set deletion_protection to false and apply it first", key)
+	}
+
+	return nil
+}
+
+// customizeDiffDeletionProtection refuses the plans replacing the resource,
+// i.e. changing one of its ForceNew keys, while its deletion protection is
+// enabled
+func customizeDiffDeletionProtection(s map[string]*schema.Schema) schema.CustomizeDiffFunc {
+	keys := forceNewKeys(s)
+
+	return func(d *schema.ResourceDiff, meta interface{}) error {
+		for _, key := range keys {
+			if err := checkDeletionProtectionReplace(d, key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// forceNewKeys returns the sorted ForceNew keys of the schema, changing one
+// of them replaces the resource
+func forceNewKeys(s map[string]*schema.Schema) []string {
+	keys := make([]string, 0)
+	for key, v := range s {
+		if v.ForceNew {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// importStateDeletionProtection imports the resource by ID with its deletion
+// protection disabled, the API doesn't hold it
+func importStateDeletionProtection(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if err := d.Set("deletion_protection", false); err != nil {
+		return nil, err
+	}
+
+	return schema.ImportStatePassthrough(d, meta)
+}
@@ -0,0 +1,75 @@
+package exoscale
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// exoConfig represents the configuration file of the exo CLI
+type exoConfig struct {
+	DefaultAccount string       `toml:"defaultAccount"`
+	Accounts       []exoAccount `toml:"accounts"`
+}
+
+// exoAccount represents an account of the exo CLI configuration
+type exoAccount struct {
+	Name        string `toml:"name"`
+	Key         string `toml:"key"`
+	Secret      string `toml:"secret"`
+	Endpoint    string `toml:"endpoint"`
+	DNSEndpoint string `toml:"dnsEndpoint"`
+	DefaultZone string `toml:"defaultZone"`
+}
+
+// exoConfigFilename returns the location of the exo CLI configuration file
+func exoConfigFilename(homeDir string) string {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		configDir = filepath.Join(homeDir, ".config")
+	}
+
+	return filepath.Join(configDir, "exoscale", "exoscale.toml")
+}
+
+// loadExoConfig reads the exo CLI configuration file
+func loadExoConfig(filename string) (*exoConfig, error) {
+	config := new(exoConfig)
+	if _, err := toml.DecodeFile(filename, config); err != nil {
+		return nil, fmt.Errorf("Config file not loaded: %s", err)
+	}
+
+	return config, nil
+}
+
+// account returns the account of the given name, or the default one
+func (c *exoConfig) account(name string) (*exoAccount, error) {
+	names := make([]string, len(c.Accounts))
+	for i, account := range c.Accounts {
+		names[i] = account.Name
+	}
+
+	if name == "" {
+		name = c.DefaultAccount
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("no account given and no default account set. Existing accounts: %s", strings.Join(names, ", "))
+	}
+
+	for i := range c.Accounts {
+		if c.Accounts[i].Name == name {
+			account := &c.Accounts[i]
+			if account.Key == "" || account.Secret == "" {
+				return nil, fmt.Errorf("account %q has no key or secret", name)
+			}
+
+			return account, nil
+		}
+	}
+
+	return nil, fmt.Errorf("account %q not found. Existing accounts: %s", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,235 @@
+package exoscale
+
+import (
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const testExoConfig = `
+defaultaccount = "prod"
+
+[[accounts]]
+account = "acme"
+defaultZone = "ch-gva-2"
+endpoint = "https://api.exoscale.ch/compute"
+key = "EXOprod"
+name = "prod"
+secret = "prod-secret"
+
+[[accounts]]
+account = "acme"
+defaultZone = "de-fra-1"
+endpoint = "https://api.example.net/compute"
+dnsEndpoint = "https://dns.example.net/dns"
+key = "EXOstaging"
+name = "staging"
+secret = "staging-secret"
+`
+
+func testExoConfigFile(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "exoscale")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(dir, "exoscale.toml")
+	if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return filename, func() { os.RemoveAll(dir) } // nolint: errcheck
+}
+
+// testUnsetCredentialsEnv removes the credentials from the environment
+func testUnsetCredentialsEnv() func() {
+	env := make(map[string]string)
+	for _, name := range []string{
+		"EXOSCALE_KEY", "EXOSCALE_API_KEY", "CLOUDSTACK_KEY", "CLOUDSTACK_API_KEY",
+		"EXOSCALE_SECRET", "EXOSCALE_SECRET_KEY", "EXOSCALE_API_SECRET", "CLOUDSTACK_SECRET", "CLOUDSTACK_SECRET_KEY",
+		"EXOSCALE_ENDPOINT", "EXOSCALE_COMPUTE_ENDPOINT", "CLOUDSTACK_ENDPOINT", "EXOSCALE_DNS_ENDPOINT",
+		"EXOSCALE_ACCOUNT", "EXOSCALE_ZONE",
+	} {
+		if v, ok := os.LookupEnv(name); ok {
+			env[name] = v
+			os.Unsetenv(name) // nolint: errcheck
+		}
+	}
+
+	return func() {
+		for name, v := range env {
+			os.Setenv(name, v) // nolint: errcheck
+		}
+	}
+}
+
+func TestExoConfigAccount(t *testing.T) {
+	filename, cleanup := testExoConfigFile(t, testExoConfig)
+	defer cleanup()
+
+	config, err := loadExoConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := config.account("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Name != "prod" || account.Key != "EXOprod" || account.DefaultZone != "ch-gva-2" {
+		t.Errorf("expected the default account, got %#v", account)
+	}
+
+	account, err = config.account("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Secret != "staging-secret" || account.DNSEndpoint != "https://dns.example.net/dns" {
+		t.Errorf("expected the staging account, got %#v", account)
+	}
+
+	_, err = config.account("dev")
+	if err == nil {
+		t.Fatal("expected an error, got none")
+	}
+	if !strings.Contains(err.Error(), "Existing accounts: prod, staging") {
+		t.Errorf("expected the accounts to be listed, got %q", err)
+	}
+}
+
+func TestExoConfigNoDefaultAccount(t *testing.T) {
+	filename, cleanup := testExoConfigFile(t, strings.Replace(testExoConfig, `defaultaccount = "prod"`, "", 1))
+	defer cleanup()
+
+	config, err := loadExoConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := config.account(""); err == nil || !strings.Contains(err.Error(), "prod, staging") {
+		t.Errorf("expected an error listing the accounts, got %v", err)
+	}
+}
+
+func TestProviderConfigureExoConfig(t *testing.T) {
+	filename, cleanup := testExoConfigFile(t, testExoConfig)
+	defer cleanup()
+	defer testUnsetCredentialsEnv()()
+
+	for _, tc := range []struct {
+		desc        string
+		raw         map[string]interface{}
+		key         string
+		endpoint    string
+		dnsEndpoint string
+		zone        string
+		err         string
+	}{
+		{
+			desc:        "default account",
+			raw:         map[string]interface{}{"config": filename},
+			key:         "EXOprod",
+			endpoint:    "https://api.exoscale.ch/compute",
+			dnsEndpoint: "https://api.exoscale.ch/dns",
+			zone:        "ch-gva-2",
+		},
+		{
+			desc:        "named account",
+			raw:         map[string]interface{}{"config": filename, "account": "staging"},
+			key:         "EXOstaging",
+			endpoint:    "https://api.example.net/compute",
+			dnsEndpoint: "https://dns.example.net/dns",
+			zone:        "de-fra-1",
+		},
+		{
+			desc:        "provider zone",
+			raw:         map[string]interface{}{"config": filename, "zone": "at-vie-1"},
+			key:         "EXOprod",
+			endpoint:    "https://api.exoscale.ch/compute",
+			dnsEndpoint: "https://api.exoscale.ch/dns",
+			zone:        "at-vie-1",
+		},
+		{
+			desc: "unknown account",
+			raw:  map[string]interface{}{"config": filename, "account": "dev"},
+			err:  "Existing accounts: prod, staging",
+		},
+		{
+			desc: "explicit credentials",
+			raw: map[string]interface{}{
+				"config":  filename,
+				"account": "staging",
+				"key":     "EXOkey",
+				"secret":  "secret",
+			},
+			key:         "EXOkey",
+			endpoint:    defaultComputeEndpoint,
+			dnsEndpoint: defaultDNSEndpoint,
+		},
+	} {
+		d := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, tc.raw)
+		meta, err := providerConfigure(d)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: expected error %q, got %v", tc.desc, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tc.desc, err)
+			continue
+		}
+
+		config := meta.(*BaseConfig)
+		if config.key != tc.key || config.computeEndpoint != tc.endpoint || config.dnsEndpoint != tc.dnsEndpoint || config.zone != tc.zone {
+			t.Errorf("%s: unexpected configuration: %q, %q, %q, %q", tc.desc, config.key, config.computeEndpoint, config.dnsEndpoint, config.zone)
+		}
+	}
+}
+
+func TestProviderConfigureExoConfigFallback(t *testing.T) {
+	if usr, err := user.Current(); err == nil {
+		if _, err := os.Stat(filepath.Join(usr.HomeDir, ".cloudstack.ini")); err == nil {
+			t.Skip("~/.cloudstack.ini takes precedence over the exo CLI configuration")
+		}
+	}
+
+	filename, cleanup := testExoConfigFile(t, testExoConfig)
+	defer cleanup()
+	defer testUnsetCredentialsEnv()()
+
+	configHome := filepath.Join(filepath.Dir(filename), "config")
+	if err := os.MkdirAll(filepath.Join(configHome, "exoscale"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filename, filepath.Join(configHome, "exoscale", "exoscale.toml")); err != nil {
+		t.Fatal(err)
+	}
+
+	xdg, xdgOK := os.LookupEnv("XDG_CONFIG_HOME")
+	os.Setenv("XDG_CONFIG_HOME", configHome) // nolint: errcheck
+	defer func() {
+		if xdgOK {
+			os.Setenv("XDG_CONFIG_HOME", xdg) // nolint: errcheck
+		} else {
+			os.Unsetenv("XDG_CONFIG_HOME") // nolint: errcheck
+		}
+	}()
+
+	d := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, map[string]interface{}{
+		"config": filepath.Join(configHome, "missing.ini"),
+	})
+	meta, err := providerConfigure(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key := meta.(*BaseConfig).key; key != "EXOprod" {
+		t.Errorf("expected the key of the default account, got %q", key)
+	}
+}
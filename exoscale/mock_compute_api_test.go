@@ -0,0 +1,3107 @@
+package exoscale
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/exoscale/egoscale"
+)
+
+// mockComputeAPI is an in-process, stateful stand-in for the Exoscale
+// CloudStack-compatible compute API. It verifies the request signatures
+// produced by egoscale, keeps every resource in memory and simulates the
+// asynchronous jobs, so that the acceptance tests can run without network.
+type mockComputeAPI struct {
+	*httptest.Server
+
+	key    string
+	secret string
+
+	mu       sync.Mutex
+	handlers map[string]mockComputeHandler
+	sequence uint32
+
+	zones            []*egoscale.Zone
+	templates        []*egoscale.Template
+	isos             []*egoscale.ISO
+	serviceOfferings []*egoscale.ServiceOffering
+	networkOfferings []*egoscale.NetworkOffering
+
+	virtualMachines []*egoscale.VirtualMachine
+	volumes         []*egoscale.Volume
+	securityGroups  []*egoscale.SecurityGroup
+	affinityGroups  []*egoscale.AffinityGroup
+	sshKeyPairs     []*egoscale.SSHKeyPair
+	networks        []*egoscale.Network
+	ipAddresses     []*egoscale.IPAddress
+
+	// destroyed virtual machines, until they are expunged
+	destroyedVirtualMachines []*egoscale.VirtualMachine
+
+	userData   map[string]string
+	publicKeys map[string]*rsa.PublicKey
+	reverseDNS map[string]string
+	tags       map[string][]egoscale.ResourceTag
+	jobs       map[string]*egoscale.AsyncJobResult
+	failures   map[string][]*egoscale.ErrorResponse
+	calls      map[string]int
+}
+
+// mockComputeHandler serves one API command, async commands are answered
+// with a job identifier and their result is fetched via queryAsyncJobResult.
+type mockComputeHandler struct {
+	async bool
+	serve func(p mockParams) (interface{}, error)
+}
+
+// mockComputeZoneID is the identifier of the zone holding the templates.
+var mockComputeZoneID = egoscale.MustParseUUID("1128bd56-b4d9-4ac6-a7b9-c715b187ce11")
+
+// newMockComputeAPI starts a mock compute API accepting the given credentials.
+func newMockComputeAPI(key, secret string) *mockComputeAPI {
+	m := &mockComputeAPI{
+		key:        key,
+		secret:     secret,
+		userData:   make(map[string]string),
+		publicKeys: make(map[string]*rsa.PublicKey),
+		reverseDNS: make(map[string]string),
+		tags:       make(map[string][]egoscale.ResourceTag),
+		jobs:       make(map[string]*egoscale.AsyncJobResult),
+		failures:   make(map[string][]*egoscale.ErrorResponse),
+		calls:      make(map[string]int),
+	}
+
+	m.handlers = map[string]mockComputeHandler{
+		"queryAsyncJobResult": {serve: m.queryAsyncJobResult},
+
+		"listZones":            {serve: m.listZones},
+		"listTemplates":        {serve: m.listTemplates},
+		"listIsos":             {serve: m.listISOs},
+		"listServiceOfferings": {serve: m.listServiceOfferings},
+		"listNetworkOfferings": {serve: m.listNetworkOfferings},
+
+		"createSecurityGroup":           {serve: m.createSecurityGroup},
+		"deleteSecurityGroup":           {serve: m.deleteSecurityGroup},
+		"listSecurityGroups":            {serve: m.listSecurityGroups},
+		"authorizeSecurityGroupIngress": {async: true, serve: m.authorizeSecurityGroupIngress},
+		"authorizeSecurityGroupEgress":  {async: true, serve: m.authorizeSecurityGroupEgress},
+		"revokeSecurityGroupIngress":    {async: true, serve: m.revokeSecurityGroupIngress},
+		"revokeSecurityGroupEgress":     {async: true, serve: m.revokeSecurityGroupEgress},
+
+		"createAffinityGroup": {async: true, serve: m.createAffinityGroup},
+		"deleteAffinityGroup": {async: true, serve: m.deleteAffinityGroup},
+		"listAffinityGroups":  {serve: m.listAffinityGroups},
+
+		"updateVMAffinityGroup": {async: true, serve: m.updateVMAffinityGroup},
+
+		"createSSHKeyPair":   {serve: m.createSSHKeyPair},
+		"registerSSHKeyPair": {serve: m.registerSSHKeyPair},
+		"deleteSSHKeyPair":   {serve: m.deleteSSHKeyPair},
+		"listSSHKeyPairs":    {serve: m.listSSHKeyPairs},
+
+		"deployVirtualMachine":               {async: true, serve: m.deployVirtualMachine},
+		"startVirtualMachine":                {async: true, serve: m.startVirtualMachine},
+		"stopVirtualMachine":                 {async: true, serve: m.stopVirtualMachine},
+		"rebootVirtualMachine":               {async: true, serve: m.rebootVirtualMachine},
+		"destroyVirtualMachine":              {async: true, serve: m.destroyVirtualMachine},
+		"recoverVirtualMachine":              {serve: m.recoverVirtualMachine},
+		"expungeVirtualMachine":              {async: true, serve: m.expungeVirtualMachine},
+		"scaleVirtualMachine":                {async: true, serve: m.scaleVirtualMachine},
+		"restoreVirtualMachine":              {async: true, serve: m.restoreVirtualMachine},
+		"resetSSHKeyForVirtualMachine":       {async: true, serve: m.resetSSHKeyForVirtualMachine},
+		"updateReverseDnsForVirtualMachine":  {serve: m.updateReverseDNSForVirtualMachine},
+		"queryReverseDnsForVirtualMachine":   {serve: m.queryReverseDNSForVirtualMachine},
+		"deleteReverseDnsFromVirtualMachine": {serve: m.deleteReverseDNSFromVirtualMachine},
+		"attachIso":                          {async: true, serve: m.attachISO},
+		"detachIso":                          {async: true, serve: m.detachISO},
+		"resetPasswordForVirtualMachine":     {async: true, serve: m.resetPasswordForVirtualMachine},
+		"updateVirtualMachine":               {serve: m.updateVirtualMachine},
+		"listVirtualMachines":                {serve: m.listVirtualMachines},
+		"getVMPassword":                      {serve: m.getVMPassword},
+		"getVirtualMachineUserData":          {serve: m.getVirtualMachineUserData},
+
+		"listVolumes":  {serve: m.listVolumes},
+		"resizeVolume": {async: true, serve: m.resizeVolume},
+
+		"createNetwork": {serve: m.createNetwork},
+		"updateNetwork": {async: true, serve: m.updateNetwork},
+		"deleteNetwork": {async: true, serve: m.deleteNetwork},
+		"listNetworks":  {serve: m.listNetworks},
+
+		"listNics":                          {serve: m.listNics},
+		"addNicToVirtualMachine":            {async: true, serve: m.addNicToVirtualMachine},
+		"removeNicFromVirtualMachine":       {async: true, serve: m.removeNicFromVirtualMachine},
+		"updateDefaultNicForVirtualMachine": {async: true, serve: m.updateDefaultNicForVirtualMachine},
+		"updateVmNicIp":                     {async: true, serve: m.updateVMNicIP},
+		"addIpToNic":                        {async: true, serve: m.addIPToNic},
+		"removeIpFromNic":                   {async: true, serve: m.removeIPFromNic},
+		"activateIp6":                       {async: true, serve: m.activateIP6},
+
+		"associateIpAddress":    {async: true, serve: m.associateIPAddress},
+		"disassociateIpAddress": {async: true, serve: m.disassociateIPAddress},
+		"updateIpAddress":       {async: true, serve: m.updateIPAddress},
+
+		"updateReverseDnsForPublicIpAddress":  {serve: m.updateReverseDNSForPublicIPAddress},
+		"queryReverseDnsForPublicIpAddress":   {serve: m.queryReverseDNSForPublicIPAddress},
+		"deleteReverseDnsFromPublicIpAddress": {serve: m.deleteReverseDNSFromPublicIPAddress},
+		"listPublicIpAddresses":               {serve: m.listPublicIPAddresses},
+
+		"createTags": {async: true, serve: m.createTags},
+		"deleteTags": {async: true, serve: m.deleteTags},
+		"listTags":   {serve: m.listTags},
+	}
+
+	m.seed()
+	m.Server = httptest.NewServer(m)
+
+	return m
+}
+
+// newMockComputeConfig starts a mock compute API and returns a provider
+// configuration pointing to it, the caller is responsible for closing the mock.
+func newMockComputeConfig(maxAttempts int) (*mockComputeAPI, *BaseConfig) {
+	key, secret := "EXOmock", "mock-secret"
+	api := newMockComputeAPI(key, secret)
+
+	return api, &BaseConfig{
+		key:              key,
+		secret:           secret,
+		timeout:          defaultTimeout,
+		computeEndpoint:  api.URL,
+		retryMaxAttempts: maxAttempts,
+		retryBackoff:     time.Millisecond,
+	}
+}
+
+// seed fills the catalogue with the zones, templates and offerings used by the tests.
+func (m *mockComputeAPI) seed() {
+	m.zones = []*egoscale.Zone{{
+		ID:          mockComputeZoneID,
+		Name:        defaultExoscaleZone,
+		NetworkType: "Basic",
+	}, {
+		ID:          egoscale.MustParseUUID("35eb7739-d19e-45f7-a581-4687c54d6d02"),
+		Name:        testAccZone2,
+		NetworkType: "Basic",
+	}}
+
+	m.templates = []*egoscale.Template{{
+		ID:              egoscale.MustParseUUID("095250e3-7c56-441a-a25b-100a3d3f5a6e"),
+		Name:            defaultExoscaleTemplate,
+		DisplayText:     defaultExoscaleTemplate,
+		Details:         map[string]string{"username": "ubuntu"},
+		OsTypeName:      "Ubuntu 18.04 LTS",
+		IsFeatured:      true,
+		IsPublic:        true,
+		IsReady:         true,
+		PasswordEnabled: true,
+		SSHKeyEnabled:   true,
+		Size:            10 << 30,
+		ZoneID:          mockComputeZoneID,
+		ZoneName:        defaultExoscaleZone,
+	}, {
+		ID:          egoscale.MustParseUUID("8a1b7d38-3c8a-4e6b-9d39-2a5e7fa2c0b1"),
+		Name:        "Linux Debian 9 64-bit",
+		DisplayText: "Linux Debian 9 64-bit",
+		Details:     map[string]string{"username": "debian"},
+		OsTypeName:  "Debian GNU/Linux 9 (64-bit)",
+		IsFeatured:  true,
+		IsPublic:    true,
+		IsReady:     true,
+		Size:        10 << 30,
+		ZoneID:      mockComputeZoneID,
+		ZoneName:    defaultExoscaleZone,
+	}, {
+		ID:              egoscale.MustParseUUID("d3b1c2e4-9f0a-4b7c-8e6d-5a4f3c2b1e07"),
+		Name:            testAccWindowsTemplate,
+		DisplayText:     testAccWindowsTemplate,
+		Details:         map[string]string{"username": "Administrator"},
+		OsTypeName:      "Windows Server 2019 (64-bit)",
+		IsFeatured:      true,
+		IsPublic:        true,
+		IsReady:         true,
+		PasswordEnabled: true,
+		Size:            50 << 30,
+		ZoneID:          mockComputeZoneID,
+		ZoneName:        defaultExoscaleZone,
+	}}
+
+	m.isos = []*egoscale.ISO{{
+		ID:          egoscale.MustParseUUID("5b3d9c6e-2a7f-4a51-9c0e-8f4b0d1e6a27"),
+		Name:        testAccISO,
+		DisplayText: testAccISO,
+		Bootable:    true,
+		IsFeatured:  true,
+		IsPublic:    true,
+		IsReady:     true,
+		ZoneID:      mockComputeZoneID,
+		ZoneName:    defaultExoscaleZone,
+	}, {
+		ID:          egoscale.MustParseUUID("c0f2e7a1-6d4b-4e3a-b8c5-1a9d7e2f4b60"),
+		Name:        testAccISO2,
+		DisplayText: testAccISO2,
+		Bootable:    true,
+		IsFeatured:  true,
+		IsPublic:    true,
+		IsReady:     true,
+		ZoneID:      mockComputeZoneID,
+		ZoneName:    defaultExoscaleZone,
+	}}
+
+	for i, size := range []string{"Micro", "Tiny", "Small", "Medium", "Large"} {
+		m.serviceOfferings = append(m.serviceOfferings, &egoscale.ServiceOffering{
+			ID:          m.newUUID(),
+			Name:        size,
+			Displaytext: size,
+			CPUNumber:   1 << uint(i/2),
+			CPUSpeed:    2198,
+			Memory:      512 << uint(i),
+		})
+	}
+
+	m.networkOfferings = []*egoscale.NetworkOffering{{
+		ID:          m.newUUID(),
+		Name:        defaultExoscaleNetworkOffering,
+		DisplayText: "Private Network",
+		GuestIPType: "Isolated",
+		State:       "Enabled",
+		TrafficType: "Guest",
+	}}
+
+	m.securityGroups = []*egoscale.SecurityGroup{{
+		ID:          m.newUUID(),
+		Name:        "default",
+		Description: "Default Security Group",
+	}}
+}
+
+func (m *mockComputeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		m.writeError(w, "errorresponse", mockComputeError(egoscale.MalformedParameterError, "%s", err))
+		return
+	}
+
+	p := mockParams{r.Form}
+	command := p.Get("command")
+	responseKey := strings.ToLower(command) + "response"
+
+	if err := m.verifySignature(p); err != nil {
+		m.writeError(w, responseKey, err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls[command]++
+
+	handler, ok := m.handlers[command]
+	if !ok {
+		m.writeError(w, "errorresponse", mockComputeError(egoscale.UnsupportedActionError, "The given command %q does not exist", command))
+		return
+	}
+
+	var (
+		result interface{}
+		err    error
+	)
+	if failures := m.failures[command]; len(failures) > 0 {
+		err = failures[0]
+		m.failures[command] = failures[1:]
+	} else {
+		result, err = handler.serve(p)
+	}
+
+	if handler.async {
+		job := &egoscale.AsyncJobResult{
+			Cmd:       command,
+			Created:   time.Now().Format("2006-01-02T15:04:05-0700"),
+			JobID:     m.newUUID(),
+			JobStatus: egoscale.Success,
+		}
+
+		var raw json.RawMessage
+		if err != nil {
+			job.JobStatus = egoscale.Failure
+			job.JobResultType = "object"
+			raw, _ = json.Marshal(err)
+		} else {
+			job.JobResultType = "object"
+			raw, _ = json.Marshal(result)
+		}
+		job.JobResult = &raw
+		m.jobs[job.JobID.String()] = job
+
+		m.writeJSON(w, http.StatusOK, map[string]interface{}{
+			responseKey: map[string]interface{}{
+				"jobid": job.JobID,
+			},
+		})
+		return
+	}
+
+	if err != nil {
+		m.writeError(w, responseKey, err)
+		return
+	}
+
+	m.writeJSON(w, http.StatusOK, map[string]interface{}{responseKey: result})
+}
+
+// failNext makes the next call of the command fail with the given error,
+// as the job result for the async commands.
+func (m *mockComputeAPI) failNext(command string, err *egoscale.ErrorResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.failures[command] = append(m.failures[command], err)
+}
+
+// callCount returns how many times the command has been received.
+func (m *mockComputeAPI) callCount(command string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.calls[command]
+}
+
+// verifySignature checks the request the same way CloudStack does.
+func (m *mockComputeAPI) verifySignature(p mockParams) error {
+	signature := p.Get("signature")
+	if p.Get("apikey") != m.key || signature == "" {
+		return mockComputeError(egoscale.Unauthorized, "unable to verify user credentials and/or request signature")
+	}
+
+	params := url.Values{}
+	for k, vs := range p.Values {
+		if k != "signature" {
+			params[k] = vs
+		}
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		for _, v := range params[k] {
+			if buf.Len() > 0 {
+				buf.WriteByte('&')
+			}
+			buf.WriteString(k)
+			buf.WriteByte('=')
+			buf.WriteString(strings.Replace(url.QueryEscape(v), "+", "%20", -1))
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(m.secret))
+	mac.Write([]byte(strings.ToLower(buf.String()))) // nolint: errcheck
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return mockComputeError(egoscale.Unauthorized, "unable to verify user credentials and/or request signature")
+	}
+
+	if expires := p.Get("expires"); expires != "" {
+		t, err := time.Parse("2006-01-02T15:04:05-0700", expires)
+		if err != nil || time.Now().After(t) {
+			return mockComputeError(egoscale.Unauthorized, "the request has expired")
+		}
+	}
+
+	return nil
+}
+
+func (m *mockComputeAPI) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) // nolint: errcheck
+}
+
+func (m *mockComputeAPI) writeError(w http.ResponseWriter, key string, err error) {
+	e, ok := err.(*egoscale.ErrorResponse)
+	if !ok {
+		e = mockComputeError(egoscale.InternalError, "%s", err)
+	}
+
+	m.writeJSON(w, int(e.ErrorCode), map[string]interface{}{key: e})
+}
+
+// mockComputeError builds an API error.
+func mockComputeError(code egoscale.ErrorCode, format string, args ...interface{}) *egoscale.ErrorResponse {
+	csCode := egoscale.ServerAPIException
+	if code == egoscale.ParamError {
+		csCode = egoscale.InvalidParameterValueException
+	}
+
+	return &egoscale.ErrorResponse{
+		ErrorCode:   code,
+		CSErrorCode: csCode,
+		ErrorText:   fmt.Sprintf(format, args...),
+	}
+}
+
+// mockNotFound builds the error returned when a resource doesn't exist.
+func mockNotFound(kind string, id interface{}) *egoscale.ErrorResponse {
+	return mockComputeError(egoscale.ParamError, "Unable to find %s with specified id %v", kind, id)
+}
+
+// mockSuccess is the body of a successful boolean response.
+func mockSuccess() map[string]interface{} {
+	return map[string]interface{}{"success": true}
+}
+
+func (m *mockComputeAPI) newUUID() *egoscale.UUID {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return egoscale.MustParseUUID(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]))
+}
+
+// next returns a monotonic sequence, used to hand out addresses.
+func (m *mockComputeAPI) next() uint32 {
+	m.sequence++
+	return m.sequence
+}
+
+func (m *mockComputeAPI) newIPv4(prefix byte) net.IP {
+	n := m.next()
+	return net.IPv4(prefix, 100, byte(n>>8), byte(n)).To4()
+}
+
+func (m *mockComputeAPI) newMAC() egoscale.MACAddress {
+	n := m.next()
+	return egoscale.MAC48(0x06, 0x00, 0x42, byte(n>>16), byte(n>>8), byte(n))
+}
+
+// paginate applies the page and pagesize parameters to a list of items.
+func paginate(p mockParams, count int) (int, int) {
+	page := int(p.int64("page"))
+	pageSize := int(p.int64("pagesize"))
+	if page <= 0 || pageSize <= 0 {
+		return 0, count
+	}
+
+	start := (page - 1) * pageSize
+	if start > count {
+		start = count
+	}
+	end := start + pageSize
+	if end > count {
+		end = count
+	}
+
+	return start, end
+}
+
+// mockParams wraps the query parameters of a request.
+type mockParams struct {
+	url.Values
+}
+
+func (p mockParams) uuid(key string) (*egoscale.UUID, error) {
+	v := p.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+
+	id, err := egoscale.ParseUUID(v)
+	if err != nil {
+		return nil, mockComputeError(egoscale.ParamError, "Unable to execute API command due to invalid value. Invalid parameter %s value=%s due to incorrect long value format, or entity does not exist or due to incorrect parameter annotation for the field in api cmd class.", key, v)
+	}
+
+	return id, nil
+}
+
+func (p mockParams) uuids(key string) ([]egoscale.UUID, error) {
+	values := p.strings(key)
+	ids := make([]egoscale.UUID, 0, len(values))
+	for _, v := range values {
+		id, err := egoscale.ParseUUID(v)
+		if err != nil {
+			return nil, mockComputeError(egoscale.ParamError, "Invalid parameter %s value=%s", key, v)
+		}
+		ids = append(ids, *id)
+	}
+
+	return ids, nil
+}
+
+func (p mockParams) strings(key string) []string {
+	v := p.Get(key)
+	if v == "" {
+		return nil
+	}
+
+	return strings.Split(v, ",")
+}
+
+func (p mockParams) int64(key string) int64 {
+	i, _ := strconv.ParseInt(p.Get(key), 10, 64)
+	return i
+}
+
+func (p mockParams) bool(key string) *bool {
+	v := p.Get(key)
+	if v == "" {
+		return nil
+	}
+
+	b := v == "true"
+	return &b
+}
+
+func (p mockParams) ip(key string) net.IP {
+	return net.ParseIP(p.Get(key))
+}
+
+// list reads a list of structures, e.g. tags[0].key=a&tags[1].key=b.
+func (p mockParams) list(prefix string) []map[string]string {
+	items := make(map[int]map[string]string)
+	for k := range p.Values {
+		if !strings.HasPrefix(k, prefix+"[") {
+			continue
+		}
+
+		rest := k[len(prefix)+1:]
+		end := strings.Index(rest, "].")
+		if end < 0 {
+			continue
+		}
+
+		i, err := strconv.Atoi(rest[:end])
+		if err != nil {
+			continue
+		}
+
+		if items[i] == nil {
+			items[i] = make(map[string]string)
+		}
+		items[i][rest[end+2:]] = p.Get(k)
+	}
+
+	indexes := make([]int, 0, len(items))
+	for i := range items {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	list := make([]map[string]string, len(indexes))
+	for i, index := range indexes {
+		list[i] = items[index]
+	}
+
+	return list
+}
+
+// details reads a map, e.g. details[0].ip6=true.
+func (p mockParams) details(prefix string) map[string]string {
+	details := make(map[string]string)
+	for _, item := range p.list(prefix) {
+		for k, v := range item {
+			details[k] = v
+		}
+	}
+
+	return details
+}
+
+// matchTags tells if the resource carries all the tags requested.
+func (m *mockComputeAPI) matchTags(p mockParams, id *egoscale.UUID) bool {
+	for _, want := range p.list("tags") {
+		found := false
+		for _, tag := range m.tags[id.String()] {
+			if tag.Key == want["key"] && tag.Value == want["value"] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *mockComputeAPI) resourceTags(id *egoscale.UUID) []egoscale.ResourceTag {
+	tags := m.tags[id.String()]
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return append([]egoscale.ResourceTag{}, tags...)
+}
+
+func (m *mockComputeAPI) queryAsyncJobResult(p mockParams) (interface{}, error) {
+	id, err := p.uuid("jobid")
+	if err != nil {
+		return nil, err
+	}
+
+	if id == nil {
+		return nil, mockComputeError(egoscale.ParamError, "Unable to execute API command queryasyncjobresult due to missing parameter jobid")
+	}
+
+	job, ok := m.jobs[id.String()]
+	if !ok {
+		return nil, mockNotFound("async job", id)
+	}
+
+	return job, nil
+}
+
+func (m *mockComputeAPI) listZones(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &egoscale.ListZonesResponse{}
+	for _, zone := range m.zones {
+		if id != nil && !zone.ID.Equal(*id) {
+			continue
+		}
+		if name := p.Get("name"); name != "" && !strings.EqualFold(name, zone.Name) {
+			continue
+		}
+		resp.Zone = append(resp.Zone, *zone)
+	}
+	resp.Count = len(resp.Zone)
+
+	return resp, nil
+}
+
+func (m *mockComputeAPI) findZone(p mockParams) (*egoscale.Zone, error) {
+	id, err := p.uuid("zoneid")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, zone := range m.zones {
+		if id != nil && zone.ID.Equal(*id) {
+			return zone, nil
+		}
+	}
+
+	return nil, mockNotFound("zone", p.Get("zoneid"))
+}
+
+func (m *mockComputeAPI) listTemplates(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+	zoneID, err := p.uuid("zoneid")
+	if err != nil {
+		return nil, err
+	}
+
+	filter := p.Get("templatefilter")
+	resp := &egoscale.ListTemplatesResponse{}
+	for _, template := range m.templates {
+		if id != nil && !template.ID.Equal(*id) {
+			continue
+		}
+		if zoneID != nil && !template.ZoneID.Equal(*zoneID) {
+			continue
+		}
+		if name := p.Get("name"); name != "" && !strings.EqualFold(name, template.Name) {
+			continue
+		}
+		if filter == "featured" && !template.IsFeatured {
+			continue
+		}
+		if (filter == "self" || filter == "selfexecutable") && template.IsFeatured {
+			continue
+		}
+		resp.Template = append(resp.Template, *template)
+	}
+	resp.Count = len(resp.Template)
+
+	return resp, nil
+}
+
+func (m *mockComputeAPI) listISOs(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+	zoneID, err := p.uuid("zoneid")
+	if err != nil {
+		return nil, err
+	}
+
+	filter := p.Get("isofilter")
+	resp := &egoscale.ListISOsResponse{}
+	for _, iso := range m.isos {
+		if id != nil && !iso.ID.Equal(*id) {
+			continue
+		}
+		if zoneID != nil && !iso.ZoneID.Equal(*zoneID) {
+			continue
+		}
+		if name := p.Get("name"); name != "" && !strings.EqualFold(name, iso.Name) {
+			continue
+		}
+		if filter == "featured" && !iso.IsFeatured {
+			continue
+		}
+		if (filter == "self" || filter == "selfexecutable") && iso.IsFeatured {
+			continue
+		}
+		resp.ISO = append(resp.ISO, *iso)
+	}
+	resp.Count = len(resp.ISO)
+
+	return resp, nil
+}
+
+func (m *mockComputeAPI) listServiceOfferings(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &egoscale.ListServiceOfferingsResponse{}
+	for _, offering := range m.serviceOfferings {
+		if id != nil && !offering.ID.Equal(*id) {
+			continue
+		}
+		if name := p.Get("name"); name != "" && !strings.EqualFold(name, offering.Name) {
+			continue
+		}
+		resp.ServiceOffering = append(resp.ServiceOffering, *offering)
+	}
+	resp.Count = len(resp.ServiceOffering)
+
+	return resp, nil
+}
+
+func (m *mockComputeAPI) findServiceOffering(p mockParams) (*egoscale.ServiceOffering, error) {
+	id, err := p.uuid("serviceofferingid")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, offering := range m.serviceOfferings {
+		if id != nil && offering.ID.Equal(*id) {
+			return offering, nil
+		}
+	}
+
+	return nil, mockNotFound("service offering", p.Get("serviceofferingid"))
+}
+
+func (m *mockComputeAPI) listNetworkOfferings(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &egoscale.ListNetworkOfferingsResponse{}
+	for _, offering := range m.networkOfferings {
+		if id != nil && !offering.ID.Equal(*id) {
+			continue
+		}
+		if name := p.Get("name"); name != "" && !strings.EqualFold(name, offering.Name) {
+			continue
+		}
+		resp.NetworkOffering = append(resp.NetworkOffering, *offering)
+	}
+	resp.Count = len(resp.NetworkOffering)
+
+	return resp, nil
+}
+
+func (m *mockComputeAPI) createSecurityGroup(p mockParams) (interface{}, error) {
+	name := p.Get("name")
+	if name == "" {
+		return nil, mockComputeError(egoscale.ParamError, "Unable to execute API command createsecuritygroup due to missing parameter name")
+	}
+
+	for _, sg := range m.securityGroups {
+		if strings.EqualFold(sg.Name, name) {
+			return nil, mockComputeError(egoscale.ParamError, "Unable to create security group, a group with name %s already exists.", name)
+		}
+	}
+
+	sg := &egoscale.SecurityGroup{
+		ID:          m.newUUID(),
+		Name:        name,
+		Description: p.Get("description"),
+	}
+	m.securityGroups = append(m.securityGroups, sg)
+
+	return map[string]interface{}{"securitygroup": sg}, nil
+}
+
+func (m *mockComputeAPI) findSecurityGroup(id *egoscale.UUID, name string) *egoscale.SecurityGroup {
+	for _, sg := range m.securityGroups {
+		if id != nil && sg.ID.Equal(*id) {
+			return sg
+		}
+		if id == nil && name != "" && strings.EqualFold(sg.Name, name) {
+			return sg
+		}
+	}
+
+	return nil
+}
+
+func (m *mockComputeAPI) deleteSecurityGroup(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	sg := m.findSecurityGroup(id, p.Get("name"))
+	if sg == nil {
+		return nil, mockNotFound("security group", p.Get("id")+p.Get("name"))
+	}
+
+	if sg.Name == "default" {
+		return nil, mockComputeError(egoscale.ParamError, "The network default group can't be removed")
+	}
+
+	for _, vm := range m.virtualMachines {
+		for _, group := range vm.SecurityGroup {
+			if group.ID.Equal(*sg.ID) {
+				return nil, mockComputeError(egoscale.ResourceInUseError, "Cannot delete group when it's in use by virtual machines")
+			}
+		}
+	}
+
+	groups := m.securityGroups[:0]
+	for _, group := range m.securityGroups {
+		if group != sg {
+			groups = append(groups, group)
+		}
+	}
+	m.securityGroups = groups
+
+	return mockSuccess(), nil
+}
+
+func (m *mockComputeAPI) listSecurityGroups(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+	vmID, err := p.uuid("virtualmachineid")
+	if err != nil {
+		return nil, err
+	}
+
+	var vm *egoscale.VirtualMachine
+	if vmID != nil {
+		if vm = m.findVirtualMachine(vmID); vm == nil {
+			return nil, mockNotFound("virtual machine", vmID)
+		}
+	}
+
+	resp := &egoscale.ListSecurityGroupsResponse{}
+	for _, sg := range m.securityGroups {
+		if id != nil && !sg.ID.Equal(*id) {
+			continue
+		}
+		if name := p.Get("securitygroupname"); name != "" && !strings.EqualFold(name, sg.Name) {
+			continue
+		}
+		if keyword := p.Get("keyword"); keyword != "" && !strings.Contains(sg.Name, keyword) {
+			continue
+		}
+		if vm != nil {
+			found := false
+			for _, group := range vm.SecurityGroup {
+				found = found || group.ID.Equal(*sg.ID)
+			}
+			if !found {
+				continue
+			}
+		}
+		resp.SecurityGroup = append(resp.SecurityGroup, *sg)
+	}
+
+	if id != nil && len(resp.SecurityGroup) == 0 {
+		return nil, mockNotFound("security group", id)
+	}
+
+	start, end := paginate(p, len(resp.SecurityGroup))
+	resp.SecurityGroup = resp.SecurityGroup[start:end]
+	resp.Count = len(resp.SecurityGroup)
+
+	return resp, nil
+}
+
+// securityGroupRules builds the rules of an authorize request, one per CIDR
+// or security group, and returns the security group they belong to.
+func (m *mockComputeAPI) securityGroupRules(p mockParams) (*egoscale.SecurityGroup, []egoscale.IngressRule, error) {
+	id, err := p.uuid("securitygroupid")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sg := m.findSecurityGroup(id, p.Get("securitygroupname"))
+	if sg == nil {
+		return nil, nil, mockNotFound("security group", p.Get("securitygroupid")+p.Get("securitygroupname"))
+	}
+
+	protocol := strings.ToLower(p.Get("protocol"))
+	if protocol == "" {
+		protocol = "tcp"
+	}
+
+	rule := egoscale.IngressRule{
+		Description: p.Get("description"),
+		Protocol:    protocol,
+	}
+
+	if strings.HasPrefix(protocol, "icmp") {
+		rule.IcmpType = uint8(p.int64("icmptype"))
+		rule.IcmpCode = uint8(p.int64("icmpcode"))
+	} else if protocol == "tcp" || protocol == "udp" {
+		rule.StartPort = uint16(p.int64("startport"))
+		rule.EndPort = uint16(p.int64("endport"))
+		if rule.EndPort == 0 {
+			rule.EndPort = rule.StartPort
+		}
+		if rule.StartPort > rule.EndPort {
+			return nil, nil, mockComputeError(egoscale.ParamError, "Start port %d cannot be greater than end port %d", rule.StartPort, rule.EndPort)
+		}
+	}
+
+	rules := []egoscale.IngressRule{}
+	for _, c := range p.strings("cidrlist") {
+		cidr, err := egoscale.ParseCIDR(c)
+		if err != nil {
+			return nil, nil, mockComputeError(egoscale.ParamError, "Invalid cidr %s", c)
+		}
+
+		r := rule
+		r.RuleID = m.newUUID()
+		r.CIDR = cidr
+		rules = append(rules, r)
+	}
+
+	for _, user := range p.list("usersecuritygrouplist") {
+		group := m.findSecurityGroup(nil, user["group"])
+		if group == nil {
+			return nil, nil, mockComputeError(egoscale.ParamError, "Unable to find security group %s", user["group"])
+		}
+
+		r := rule
+		r.RuleID = m.newUUID()
+		r.SecurityGroupName = group.Name
+		rules = append(rules, r)
+	}
+
+	if len(rules) == 0 {
+		return nil, nil, mockComputeError(egoscale.ParamError, "At least one cidr or security group must be specified")
+	}
+
+	return sg, rules, nil
+}
+
+func (m *mockComputeAPI) authorizeSecurityGroupIngress(p mockParams) (interface{}, error) {
+	sg, rules, err := m.securityGroupRules(p)
+	if err != nil {
+		return nil, err
+	}
+
+	sg.IngressRule = append(sg.IngressRule, rules...)
+
+	return map[string]interface{}{
+		"securitygroup": egoscale.SecurityGroup{
+			ID:          sg.ID,
+			Name:        sg.Name,
+			Description: sg.Description,
+			IngressRule: rules,
+		},
+	}, nil
+}
+
+func (m *mockComputeAPI) authorizeSecurityGroupEgress(p mockParams) (interface{}, error) {
+	sg, rules, err := m.securityGroupRules(p)
+	if err != nil {
+		return nil, err
+	}
+
+	egress := make([]egoscale.EgressRule, len(rules))
+	for i := range rules {
+		egress[i] = egoscale.EgressRule(rules[i])
+	}
+	sg.EgressRule = append(sg.EgressRule, egress...)
+
+	return map[string]interface{}{
+		"securitygroup": egoscale.SecurityGroup{
+			ID:          sg.ID,
+			Name:        sg.Name,
+			Description: sg.Description,
+			EgressRule:  egress,
+		},
+	}, nil
+}
+
+func (m *mockComputeAPI) revokeSecurityGroupIngress(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sg := range m.securityGroups {
+		for i, rule := range sg.IngressRule {
+			if id != nil && rule.RuleID.Equal(*id) {
+				sg.IngressRule = append(sg.IngressRule[:i], sg.IngressRule[i+1:]...)
+				return mockSuccess(), nil
+			}
+		}
+	}
+
+	return nil, mockNotFound("ingress rule", p.Get("id"))
+}
+
+func (m *mockComputeAPI) revokeSecurityGroupEgress(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sg := range m.securityGroups {
+		for i, rule := range sg.EgressRule {
+			if id != nil && rule.RuleID.Equal(*id) {
+				sg.EgressRule = append(sg.EgressRule[:i], sg.EgressRule[i+1:]...)
+				return mockSuccess(), nil
+			}
+		}
+	}
+
+	return nil, mockNotFound("egress rule", p.Get("id"))
+}
+
+func (m *mockComputeAPI) findAffinityGroup(id *egoscale.UUID, name string) *egoscale.AffinityGroup {
+	for _, ag := range m.affinityGroups {
+		if id != nil && ag.ID.Equal(*id) {
+			return ag
+		}
+		if id == nil && name != "" && ag.Name == name {
+			return ag
+		}
+	}
+
+	return nil
+}
+
+// findAffinityGroups resolves the affinitygroupids or affinitygroupnames
+// parameters.
+func (m *mockComputeAPI) findAffinityGroups(p mockParams) ([]egoscale.AffinityGroup, error) {
+	affinityGroups := []egoscale.AffinityGroup{}
+	agIDs, err := p.uuids("affinitygroupids")
+	if err != nil {
+		return nil, err
+	}
+	for i := range agIDs {
+		ag := m.findAffinityGroup(&agIDs[i], "")
+		if ag == nil {
+			return nil, mockNotFound("affinity group", agIDs[i])
+		}
+		affinityGroups = append(affinityGroups, egoscale.AffinityGroup{ID: ag.ID, Name: ag.Name})
+	}
+	for _, name := range p.strings("affinitygroupnames") {
+		ag := m.findAffinityGroup(nil, name)
+		if ag == nil {
+			return nil, mockComputeError(egoscale.ParamError, "Unable to find affinity group by name %s", name)
+		}
+		affinityGroups = append(affinityGroups, egoscale.AffinityGroup{ID: ag.ID, Name: ag.Name})
+	}
+
+	return affinityGroups, nil
+}
+
+func (m *mockComputeAPI) updateVMAffinityGroup(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if vm.State != "Stopped" {
+		return nil, mockComputeError(egoscale.ParamError, "Vm %s should be stopped to update its affinity groups", vm.ID)
+	}
+
+	affinityGroups, err := m.findAffinityGroups(p)
+	if err != nil {
+		return nil, err
+	}
+	vm.AffinityGroup = affinityGroups
+
+	return m.virtualMachineResponse(vm), nil
+}
+
+func (m *mockComputeAPI) renderAffinityGroup(ag *egoscale.AffinityGroup) egoscale.AffinityGroup {
+	group := *ag
+	group.VirtualMachineIDs = nil
+	for _, vm := range m.virtualMachines {
+		for _, a := range vm.AffinityGroup {
+			if a.ID.Equal(*ag.ID) {
+				group.VirtualMachineIDs = append(group.VirtualMachineIDs, *vm.ID)
+			}
+		}
+	}
+
+	return group
+}
+
+func (m *mockComputeAPI) createAffinityGroup(p mockParams) (interface{}, error) {
+	name := p.Get("name")
+	if name == "" {
+		return nil, mockComputeError(egoscale.ParamError, "Unable to execute API command createaffinitygroup due to missing parameter name")
+	}
+
+	if m.findAffinityGroup(nil, name) != nil {
+		return nil, mockComputeError(egoscale.ParamError, "Unable to create affinity group, a group with name %s already exists.", name)
+	}
+
+	ag := &egoscale.AffinityGroup{
+		ID:          m.newUUID(),
+		Name:        name,
+		Description: p.Get("description"),
+		Type:        p.Get("type"),
+	}
+	m.affinityGroups = append(m.affinityGroups, ag)
+
+	return map[string]interface{}{"affinitygroup": m.renderAffinityGroup(ag)}, nil
+}
+
+func (m *mockComputeAPI) deleteAffinityGroup(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	ag := m.findAffinityGroup(id, p.Get("name"))
+	if ag == nil {
+		return nil, mockNotFound("affinity group", p.Get("id")+p.Get("name"))
+	}
+
+	if len(m.renderAffinityGroup(ag).VirtualMachineIDs) > 0 {
+		return nil, mockComputeError(egoscale.ResourceInUseError, "Cannot delete affinity group when it's in use by virtual machines")
+	}
+
+	groups := m.affinityGroups[:0]
+	for _, group := range m.affinityGroups {
+		if group != ag {
+			groups = append(groups, group)
+		}
+	}
+	m.affinityGroups = groups
+
+	return mockSuccess(), nil
+}
+
+func (m *mockComputeAPI) listAffinityGroups(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+	vmID, err := p.uuid("virtualmachineid")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &egoscale.ListAffinityGroupsResponse{}
+	for _, ag := range m.affinityGroups {
+		if id != nil && !ag.ID.Equal(*id) {
+			continue
+		}
+		if name := p.Get("name"); name != "" && name != ag.Name {
+			continue
+		}
+		if t := p.Get("type"); t != "" && t != ag.Type {
+			continue
+		}
+		if keyword := p.Get("keyword"); keyword != "" && !strings.Contains(ag.Name, keyword) {
+			continue
+		}
+
+		group := m.renderAffinityGroup(ag)
+		if vmID != nil {
+			found := false
+			for _, id := range group.VirtualMachineIDs {
+				found = found || id.Equal(*vmID)
+			}
+			if !found {
+				continue
+			}
+		}
+		resp.AffinityGroup = append(resp.AffinityGroup, group)
+	}
+
+	if id != nil && len(resp.AffinityGroup) == 0 {
+		return nil, mockNotFound("affinity group", id)
+	}
+
+	start, end := paginate(p, len(resp.AffinityGroup))
+	resp.AffinityGroup = resp.AffinityGroup[start:end]
+	resp.Count = len(resp.AffinityGroup)
+
+	return resp, nil
+}
+
+// mockSSHFingerprint computes the MD5 fingerprint of an SSH public key blob.
+func mockSSHFingerprint(blob []byte) string {
+	sum := md5.Sum(blob)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// mockSSHPublicKeyBlob encodes an RSA public key using the SSH wire format.
+func mockSSHPublicKeyBlob(key *rsa.PublicKey) []byte {
+	var buf bytes.Buffer
+	write := func(b []byte) {
+		binary.Write(&buf, binary.BigEndian, uint32(len(b))) // nolint: errcheck
+		buf.Write(b)
+	}
+
+	e := big.NewInt(int64(key.E)).Bytes()
+	n := key.N.Bytes()
+	if n[0]&0x80 != 0 {
+		n = append([]byte{0}, n...)
+	}
+
+	write([]byte("ssh-rsa"))
+	write(e)
+	write(n)
+
+	return buf.Bytes()
+}
+
+// mockSSHParsePublicKeyBlob decodes an RSA public key from the SSH wire format.
+func mockSSHParsePublicKeyBlob(blob []byte) (*rsa.PublicKey, error) {
+	var fields [][]byte
+	for len(blob) > 0 {
+		if len(blob) < 4 {
+			return nil, fmt.Errorf("truncated public key")
+		}
+		n := binary.BigEndian.Uint32(blob)
+		if uint32(len(blob)-4) < n {
+			return nil, fmt.Errorf("truncated public key")
+		}
+		fields = append(fields, blob[4:4+n])
+		blob = blob[4+n:]
+	}
+
+	if len(fields) != 3 || string(fields[0]) != "ssh-rsa" {
+		return nil, fmt.Errorf("not an RSA public key")
+	}
+
+	return &rsa.PublicKey{
+		E: int(new(big.Int).SetBytes(fields[1]).Int64()),
+		N: new(big.Int).SetBytes(fields[2]),
+	}, nil
+}
+
+func (m *mockComputeAPI) findSSHKeyPair(name string) *egoscale.SSHKeyPair {
+	for _, key := range m.sshKeyPairs {
+		if strings.EqualFold(key.Name, name) {
+			return key
+		}
+	}
+
+	return nil
+}
+
+func (m *mockComputeAPI) createSSHKeyPair(p mockParams) (interface{}, error) {
+	name := p.Get("name")
+	if m.findSSHKeyPair(name) != nil {
+		return nil, mockComputeError(egoscale.ParamError, "A key pair with name '%s' already exists.", name)
+	}
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	blob := mockSSHPublicKeyBlob(&private.PublicKey)
+	key := &egoscale.SSHKeyPair{
+		Name:        name,
+		Fingerprint: mockSSHFingerprint(blob),
+	}
+	m.sshKeyPairs = append(m.sshKeyPairs, key)
+	m.publicKeys[key.Fingerprint] = &private.PublicKey
+
+	resp := *key
+	resp.PrivateKey = string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(private),
+	}))
+
+	return map[string]interface{}{"keypair": resp}, nil
+}
+
+func (m *mockComputeAPI) registerSSHKeyPair(p mockParams) (interface{}, error) {
+	name := p.Get("name")
+	if m.findSSHKeyPair(name) != nil {
+		return nil, mockComputeError(egoscale.ParamError, "A key pair with name '%s' already exists.", name)
+	}
+
+	fields := strings.Fields(p.Get("publickey"))
+	if len(fields) < 2 {
+		return nil, mockComputeError(egoscale.ParamError, "Public key is invalid")
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, mockComputeError(egoscale.ParamError, "Public key is invalid")
+	}
+
+	key := &egoscale.SSHKeyPair{
+		Name:        name,
+		Fingerprint: mockSSHFingerprint(blob),
+	}
+	m.sshKeyPairs = append(m.sshKeyPairs, key)
+
+	if public, err := mockSSHParsePublicKeyBlob(blob); err == nil {
+		m.publicKeys[key.Fingerprint] = public
+	}
+
+	return map[string]interface{}{"keypair": key}, nil
+}
+
+func (m *mockComputeAPI) deleteSSHKeyPair(p mockParams) (interface{}, error) {
+	key := m.findSSHKeyPair(p.Get("name"))
+	if key == nil {
+		return nil, mockComputeError(egoscale.ParamError, "A key pair with name '%s' does not exist.", p.Get("name"))
+	}
+
+	keys := m.sshKeyPairs[:0]
+	for _, k := range m.sshKeyPairs {
+		if k != key {
+			keys = append(keys, k)
+		}
+	}
+	m.sshKeyPairs = keys
+
+	return mockSuccess(), nil
+}
+
+func (m *mockComputeAPI) listSSHKeyPairs(p mockParams) (interface{}, error) {
+	resp := &egoscale.ListSSHKeyPairsResponse{}
+	for _, key := range m.sshKeyPairs {
+		if name := p.Get("name"); name != "" && !strings.EqualFold(name, key.Name) {
+			continue
+		}
+		if fingerprint := p.Get("fingerprint"); fingerprint != "" && fingerprint != key.Fingerprint {
+			continue
+		}
+		if keyword := p.Get("keyword"); keyword != "" && !strings.Contains(key.Name, keyword) {
+			continue
+		}
+		resp.SSHKeyPair = append(resp.SSHKeyPair, *key)
+	}
+
+	start, end := paginate(p, len(resp.SSHKeyPair))
+	resp.SSHKeyPair = resp.SSHKeyPair[start:end]
+	resp.Count = len(resp.SSHKeyPair)
+
+	return resp, nil
+}
+
+func (m *mockComputeAPI) findVirtualMachine(id *egoscale.UUID) *egoscale.VirtualMachine {
+	if id == nil {
+		return nil
+	}
+
+	for _, vm := range m.virtualMachines {
+		if vm.ID.Equal(*id) {
+			return vm
+		}
+	}
+
+	return nil
+}
+
+// virtualMachine finds the virtual machine referenced by the given parameter.
+func (m *mockComputeAPI) virtualMachine(p mockParams, key string) (*egoscale.VirtualMachine, error) {
+	id, err := p.uuid(key)
+	if err != nil {
+		return nil, err
+	}
+
+	vm := m.findVirtualMachine(id)
+	if vm == nil {
+		return nil, mockNotFound("virtual machine", p.Get(key))
+	}
+
+	return vm, nil
+}
+
+// renderVirtualMachine returns a copy of the virtual machine as shown by the API.
+func (m *mockComputeAPI) renderVirtualMachine(vm *egoscale.VirtualMachine) egoscale.VirtualMachine {
+	machine := *vm
+	machine.Password = ""
+	machine.Tags = m.resourceTags(vm.ID)
+	machine.Nic = append([]egoscale.Nic{}, vm.Nic...)
+
+	machine.SecurityGroup = nil
+	for _, group := range vm.SecurityGroup {
+		if sg := m.findSecurityGroup(group.ID, ""); sg != nil {
+			machine.SecurityGroup = append(machine.SecurityGroup, *sg)
+		}
+	}
+
+	machine.AffinityGroup = nil
+	for _, group := range vm.AffinityGroup {
+		if ag := m.findAffinityGroup(group.ID, ""); ag != nil {
+			machine.AffinityGroup = append(machine.AffinityGroup, m.renderAffinityGroup(ag))
+		}
+	}
+
+	return machine
+}
+
+func (m *mockComputeAPI) virtualMachineResponse(vm *egoscale.VirtualMachine) map[string]interface{} {
+	return map[string]interface{}{"virtualmachine": m.renderVirtualMachine(vm)}
+}
+
+func (m *mockComputeAPI) deployVirtualMachine(p mockParams) (interface{}, error) {
+	zone, err := m.findZone(p)
+	if err != nil {
+		return nil, err
+	}
+
+	offering, err := m.findServiceOffering(p)
+	if err != nil {
+		return nil, err
+	}
+
+	templateID, err := p.uuid("templateid")
+	if err != nil {
+		return nil, err
+	}
+
+	var template *egoscale.Template
+	for _, t := range m.templates {
+		if templateID != nil && t.ID.Equal(*templateID) && t.ZoneID.Equal(*zone.ID) {
+			template = t
+		}
+	}
+	if template == nil {
+		return nil, mockNotFound("template", p.Get("templateid"))
+	}
+
+	keyPair := p.Get("keypair")
+	if keyPair != "" {
+		key := m.findSSHKeyPair(keyPair)
+		if key == nil {
+			return nil, mockComputeError(egoscale.ParamError, "A key pair with name '%s' was not found.", keyPair)
+		}
+		keyPair = key.Name
+	}
+
+	securityGroups := []egoscale.SecurityGroup{}
+	sgIDs, err := p.uuids("securitygroupids")
+	if err != nil {
+		return nil, err
+	}
+	for i := range sgIDs {
+		sg := m.findSecurityGroup(&sgIDs[i], "")
+		if sg == nil {
+			return nil, mockNotFound("security group", sgIDs[i])
+		}
+		securityGroups = append(securityGroups, egoscale.SecurityGroup{ID: sg.ID, Name: sg.Name})
+	}
+	for _, name := range p.strings("securitygroupnames") {
+		sg := m.findSecurityGroup(nil, name)
+		if sg == nil {
+			return nil, mockComputeError(egoscale.ParamError, "Unable to find group by name %s", name)
+		}
+		securityGroups = append(securityGroups, egoscale.SecurityGroup{ID: sg.ID, Name: sg.Name})
+	}
+	if len(securityGroups) == 0 {
+		sg := m.findSecurityGroup(nil, "default")
+		securityGroups = append(securityGroups, egoscale.SecurityGroup{ID: sg.ID, Name: sg.Name})
+	}
+
+	affinityGroups, err := m.findAffinityGroups(p)
+	if err != nil {
+		return nil, err
+	}
+
+	networkIDs, err := p.uuids("networkids")
+	if err != nil {
+		return nil, err
+	}
+	networks := make([]*egoscale.Network, len(networkIDs))
+	for i := range networkIDs {
+		network := m.findNetwork(&networkIDs[i])
+		if network == nil || !network.ZoneID.Equal(*zone.ID) {
+			return nil, mockNotFound("network", networkIDs[i])
+		}
+		for _, other := range networks[:i] {
+			if other == network {
+				return nil, mockComputeError(egoscale.ParamError, "Network %s is given more than once", network.ID)
+			}
+		}
+		networks[i] = network
+	}
+
+	id := m.newUUID()
+	name := p.Get("name")
+	if name == "" {
+		name = "VM-" + id.String()
+	}
+	displayName := p.Get("displayname")
+	if displayName == "" {
+		displayName = name
+	}
+
+	diskSize := p.int64("rootdisksize") << 30
+	if diskSize == 0 {
+		diskSize = template.Size
+	}
+	if diskSize < template.Size {
+		return nil, mockComputeError(egoscale.ParamError, "rootdisksize cannot be smaller than the template size")
+	}
+
+	details := p.details("details")
+	ip4 := details["ip4"] != "false"
+	if p.bool("ip4") != nil {
+		ip4 = *p.bool("ip4")
+	}
+	ip6 := details["ip6"] == "true"
+	if p.bool("ip6") != nil {
+		ip6 = *p.bool("ip6")
+	}
+
+	state := "Running"
+	if startVM := p.bool("startvm"); startVM != nil && !*startVM {
+		state = "Stopped"
+	}
+
+	nic := egoscale.Nic{
+		ID:               m.newUUID(),
+		IsDefault:        true,
+		MACAddress:       m.newMAC(),
+		NetworkID:        egoscale.MustParseUUID("00304a04-c7ea-4e77-a786-18bc64347bf7"),
+		NetworkName:      "defaultGuestNetwork",
+		TrafficType:      "Guest",
+		Type:             "Shared",
+		VirtualMachineID: id,
+	}
+	if ip4 {
+		nic.IPAddress = m.newIPv4(185)
+		nic.Gateway = net.IPv4(nic.IPAddress[0], nic.IPAddress[1], nic.IPAddress[2], 1).To4()
+		nic.Netmask = net.IPv4(255, 255, 255, 0).To4()
+	}
+	if ip6 {
+		m.activateNicIPv6(&nic)
+	}
+
+	nics := []egoscale.Nic{nic}
+	for _, network := range networks {
+		nics = append(nics, m.newIsolatedNic(id, network, nil))
+	}
+
+	password := strings.Replace(id.String(), "-", "", -1)[:12]
+
+	vm := &egoscale.VirtualMachine{
+		AffinityGroup:       affinityGroups,
+		CPUNumber:           offering.CPUNumber,
+		CPUSpeed:            offering.CPUSpeed,
+		Created:             time.Now().Format("2006-01-02T15:04:05-0700"),
+		Details:             map[string]string{"keyboard": p.Get("keyboard")},
+		DisplayName:         displayName,
+		ID:                  id,
+		KeyPair:             keyPair,
+		Memory:              offering.Memory,
+		Name:                name,
+		Nic:                 nics,
+		Password:            password,
+		PasswordEnabled:     template.PasswordEnabled,
+		SecurityGroup:       securityGroups,
+		ServiceOfferingID:   offering.ID,
+		ServiceOfferingName: offering.Name,
+		State:               state,
+		TemplateDisplayText: template.DisplayText,
+		TemplateID:          template.ID,
+		TemplateName:        template.Name,
+		ZoneID:              zone.ID,
+		ZoneName:            zone.Name,
+	}
+	m.virtualMachines = append(m.virtualMachines, vm)
+	m.userData[id.String()] = p.Get("userdata")
+
+	m.volumes = append(m.volumes, &egoscale.Volume{
+		ID:               m.newUUID(),
+		Name:             "ROOT-" + id.String(),
+		Size:             uint64(diskSize),
+		State:            "Ready",
+		TemplateID:       template.ID,
+		TemplateName:     template.Name,
+		Type:             "ROOT",
+		VirtualMachineID: id,
+		VMName:           name,
+		VMDisplayName:    displayName,
+		VMState:          state,
+		ZoneID:           zone.ID,
+		ZoneName:         zone.Name,
+	})
+
+	resp := m.renderVirtualMachine(vm)
+	if m.publicKey(vm) == nil {
+		// Otherwise it is only given encrypted by getVMPassword
+		resp.Password = password
+	}
+
+	return map[string]interface{}{"virtualmachine": resp}, nil
+}
+
+// setVirtualMachineState changes the state of the VM and its volumes.
+func (m *mockComputeAPI) setVirtualMachineState(vm *egoscale.VirtualMachine, state string) {
+	vm.State = state
+	for _, volume := range m.volumes {
+		if volume.VirtualMachineID.Equal(*vm.ID) {
+			volume.VMState = state
+		}
+	}
+}
+
+func (m *mockComputeAPI) startVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if vm.State != "Stopped" && vm.State != "Running" {
+		return nil, mockComputeError(egoscale.ParamError, "Unable to start a VM in state %s", vm.State)
+	}
+
+	m.setVirtualMachineState(vm, "Running")
+
+	return m.virtualMachineResponse(vm), nil
+}
+
+func (m *mockComputeAPI) stopVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if vm.State != "Stopped" && vm.State != "Running" {
+		return nil, mockComputeError(egoscale.ParamError, "Unable to stop a VM in state %s", vm.State)
+	}
+
+	m.setVirtualMachineState(vm, "Stopped")
+
+	return m.virtualMachineResponse(vm), nil
+}
+
+func (m *mockComputeAPI) rebootVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if vm.State != "Running" {
+		return nil, mockComputeError(egoscale.ParamError, "Unable to reboot a VM in state %s", vm.State)
+	}
+
+	return m.virtualMachineResponse(vm), nil
+}
+
+// restoreVirtualMachine reinstalls the VM from a template on a new root
+// volume, of the template size unless rootdisksize is given.
+func (m *mockComputeAPI) restoreVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "virtualmachineid")
+	if err != nil {
+		return nil, err
+	}
+
+	templateID, err := p.uuid("templateid")
+	if err != nil {
+		return nil, err
+	}
+	if templateID == nil {
+		templateID = vm.TemplateID
+	}
+
+	var template *egoscale.Template
+	for _, t := range m.templates {
+		if t.ID.Equal(*templateID) && t.ZoneID.Equal(*vm.ZoneID) {
+			template = t
+		}
+	}
+	if template == nil {
+		return nil, mockNotFound("template", templateID)
+	}
+
+	diskSize := p.int64("rootdisksize") << 30
+	if diskSize == 0 {
+		diskSize = template.Size
+	}
+	if diskSize < template.Size {
+		return nil, mockComputeError(egoscale.ParamError, "rootdisksize cannot be smaller than the template size")
+	}
+
+	for _, volume := range m.volumes {
+		if volume.Type == "ROOT" && volume.VirtualMachineID.Equal(*vm.ID) {
+			volume.ID = m.newUUID()
+			volume.Size = uint64(diskSize)
+			volume.TemplateID = template.ID
+			volume.TemplateName = template.Name
+		}
+	}
+
+	vm.TemplateID = template.ID
+	vm.TemplateName = template.Name
+	vm.TemplateDisplayText = template.DisplayText
+	vm.PasswordEnabled = template.PasswordEnabled
+	vm.Password = strings.Replace(m.newUUID().String(), "-", "", -1)[:12]
+
+	resp := m.renderVirtualMachine(vm)
+	resp.Password = vm.Password
+
+	return map[string]interface{}{"virtualmachine": resp}, nil
+}
+
+func (m *mockComputeAPI) resetPasswordForVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if vm.State != "Stopped" {
+		return nil, mockComputeError(egoscale.ParamError, "Vm %s should be stopped to do password reset", vm.ID)
+	}
+
+	if !vm.PasswordEnabled {
+		return nil, mockComputeError(egoscale.ParamError, "Vm %s is not password enabled", vm.ID)
+	}
+	vm.Password = strings.Replace(m.newUUID().String(), "-", "", -1)[:12]
+
+	resp := m.renderVirtualMachine(vm)
+	if m.publicKey(vm) == nil {
+		resp.Password = vm.Password
+	}
+
+	return map[string]interface{}{"virtualmachine": resp}, nil
+}
+
+// mockDomainName checks the domain name of a PTR record, it is returned
+// fully qualified.
+func mockDomainName(p mockParams) (string, error) {
+	name := strings.TrimSuffix(p.Get("domainname"), ".")
+	if i := strings.LastIndex(name, "."); i <= 0 || i == len(name)-1 {
+		return "", mockComputeError(egoscale.ParamError, "Invalid domain name %q, it must have a valid TLD", p.Get("domainname"))
+	}
+
+	return name + ".", nil
+}
+
+func (m *mockComputeAPI) renderReverseDNS(vm *egoscale.VirtualMachine) egoscale.VirtualMachine {
+	machine := m.renderVirtualMachine(vm)
+	for i := range machine.Nic {
+		nic := &machine.Nic[i]
+		nic.ReverseDNS = nil
+		if domainName, ok := m.reverseDNS[vm.ID.String()]; ok && nic.Type == "Shared" {
+			nic.ReverseDNS = []egoscale.ReverseDNS{{
+				DomainName:       domainName,
+				IPAddress:        nic.IPAddress,
+				NicID:            nic.ID,
+				VirtualMachineID: vm.ID,
+			}}
+		}
+	}
+
+	return machine
+}
+
+func (m *mockComputeAPI) updateReverseDNSForVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	domainName, err := mockDomainName(p)
+	if err != nil {
+		return nil, err
+	}
+	m.reverseDNS[vm.ID.String()] = domainName
+
+	return map[string]interface{}{"virtualmachine": m.renderReverseDNS(vm)}, nil
+}
+
+func (m *mockComputeAPI) queryReverseDNSForVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"virtualmachine": m.renderReverseDNS(vm)}, nil
+}
+
+func (m *mockComputeAPI) deleteReverseDNSFromVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+	delete(m.reverseDNS, vm.ID.String())
+
+	return mockSuccess(), nil
+}
+
+func (m *mockComputeAPI) attachISO(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "virtualmachineid")
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	var iso *egoscale.ISO
+	for _, i := range m.isos {
+		if id != nil && i.ID.Equal(*id) && i.ZoneID.Equal(*vm.ZoneID) {
+			iso = i
+		}
+	}
+	if iso == nil {
+		return nil, mockNotFound("iso", p.Get("id"))
+	}
+
+	if vm.IsoID != nil {
+		return nil, mockComputeError(egoscale.ParamError, "VM %s already has an ISO attached. Please detach current ISO file before attaching another one", vm.ID)
+	}
+	vm.IsoID = iso.ID
+	vm.IsoName = iso.Name
+	vm.IsoDisplayText = iso.DisplayText
+
+	return m.virtualMachineResponse(vm), nil
+}
+
+func (m *mockComputeAPI) detachISO(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "virtualmachineid")
+	if err != nil {
+		return nil, err
+	}
+
+	if vm.IsoID == nil {
+		return nil, mockComputeError(egoscale.ParamError, "VM %s has no ISO attached", vm.ID)
+	}
+	vm.IsoID = nil
+	vm.IsoName = ""
+	vm.IsoDisplayText = ""
+
+	return m.virtualMachineResponse(vm), nil
+}
+
+func (m *mockComputeAPI) resetSSHKeyForVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if vm.State != "Stopped" {
+		return nil, mockComputeError(egoscale.ParamError, "Vm %s should be stopped to do SSH Key reset", vm.ID)
+	}
+
+	key := m.findSSHKeyPair(p.Get("keypair"))
+	if key == nil {
+		return nil, mockComputeError(egoscale.ParamError, "A key pair with name '%s' was not found.", p.Get("keypair"))
+	}
+	vm.KeyPair = key.Name
+
+	return m.virtualMachineResponse(vm), nil
+}
+
+// removeVirtualMachine wipes the VM and its volumes from the inventory.
+func (m *mockComputeAPI) removeVirtualMachine(vm *egoscale.VirtualMachine) {
+	vms := m.virtualMachines[:0]
+	for _, v := range m.virtualMachines {
+		if v != vm {
+			vms = append(vms, v)
+		}
+	}
+	m.virtualMachines = vms
+
+	volumes := m.volumes[:0]
+	for _, volume := range m.volumes {
+		if !volume.VirtualMachineID.Equal(*vm.ID) {
+			volumes = append(volumes, volume)
+		}
+	}
+	m.volumes = volumes
+
+	delete(m.userData, vm.ID.String())
+	delete(m.tags, vm.ID.String())
+}
+
+func (m *mockComputeAPI) destroyVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	m.setVirtualMachineState(vm, "Destroyed")
+	resp := m.virtualMachineResponse(vm)
+
+	vms := m.virtualMachines[:0]
+	for _, v := range m.virtualMachines {
+		if v != vm {
+			vms = append(vms, v)
+		}
+	}
+	m.virtualMachines = vms
+	m.destroyedVirtualMachines = append(m.destroyedVirtualMachines, vm)
+
+	return resp, nil
+}
+
+// destroyedVirtualMachine takes the destroyed virtual machine referenced by
+// the given parameter out of the destroyed ones.
+func (m *mockComputeAPI) destroyedVirtualMachine(p mockParams, key string) (*egoscale.VirtualMachine, error) {
+	id, err := p.uuid(key)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, vm := range m.destroyedVirtualMachines {
+		if id != nil && vm.ID.Equal(*id) {
+			m.destroyedVirtualMachines = append(m.destroyedVirtualMachines[:i], m.destroyedVirtualMachines[i+1:]...)
+			return vm, nil
+		}
+	}
+
+	if m.findVirtualMachine(id) != nil {
+		return nil, mockComputeError(egoscale.ParamError, "Virtual machine %s is not destroyed", p.Get(key))
+	}
+
+	return nil, mockNotFound("virtual machine", p.Get(key))
+}
+
+func (m *mockComputeAPI) recoverVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.destroyedVirtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	m.setVirtualMachineState(vm, "Stopped")
+	m.virtualMachines = append(m.virtualMachines, vm)
+
+	return m.virtualMachineResponse(vm), nil
+}
+
+func (m *mockComputeAPI) expungeVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.destroyedVirtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	m.removeVirtualMachine(vm)
+
+	return mockSuccess(), nil
+}
+
+func (m *mockComputeAPI) scaleVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	offering, err := m.findServiceOffering(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if vm.State != "Stopped" {
+		return nil, mockComputeError(egoscale.ParamError, "Unable to scale a VM which is not stopped")
+	}
+
+	vm.ServiceOfferingID = offering.ID
+	vm.ServiceOfferingName = offering.Name
+	vm.CPUNumber = offering.CPUNumber
+	vm.CPUSpeed = offering.CPUSpeed
+	vm.Memory = offering.Memory
+
+	return mockSuccess(), nil
+}
+
+func (m *mockComputeAPI) updateVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := p.Values["securitygroupids"]; ok {
+		ids, err := p.uuids("securitygroupids")
+		if err != nil {
+			return nil, err
+		}
+
+		groups := make([]egoscale.SecurityGroup, 0, len(ids))
+		for i := range ids {
+			sg := m.findSecurityGroup(&ids[i], "")
+			if sg == nil {
+				return nil, mockNotFound("security group", ids[i])
+			}
+			groups = append(groups, egoscale.SecurityGroup{ID: sg.ID, Name: sg.Name})
+		}
+		vm.SecurityGroup = groups
+	}
+
+	if displayName := p.Get("displayname"); displayName != "" {
+		vm.DisplayName = displayName
+	}
+	if name := p.Get("name"); name != "" {
+		vm.Name = name
+	}
+	if _, ok := p.Values["userdata"]; ok {
+		m.userData[vm.ID.String()] = p.Get("userdata")
+	}
+	for k, v := range p.details("details") {
+		vm.Details[k] = v
+	}
+
+	return m.virtualMachineResponse(vm), nil
+}
+
+func (m *mockComputeAPI) listVirtualMachines(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+	ids, err := p.uuids("ids")
+	if err != nil {
+		return nil, err
+	}
+	zoneID, err := p.uuid("zoneid")
+	if err != nil {
+		return nil, err
+	}
+	templateID, err := p.uuid("templateid")
+	if err != nil {
+		return nil, err
+	}
+	networkID, err := p.uuid("networkid")
+	if err != nil {
+		return nil, err
+	}
+	affinityGroupID, err := p.uuid("affinitygroupid")
+	if err != nil {
+		return nil, err
+	}
+	ipAddress := p.ip("ipaddress")
+
+	resp := &egoscale.ListVirtualMachinesResponse{}
+	for _, vm := range m.virtualMachines {
+		if id != nil && !vm.ID.Equal(*id) {
+			continue
+		}
+		if len(ids) > 0 {
+			found := false
+			for i := range ids {
+				found = found || vm.ID.Equal(ids[i])
+			}
+			if !found {
+				continue
+			}
+		}
+		if zoneID != nil && !vm.ZoneID.Equal(*zoneID) {
+			continue
+		}
+		if templateID != nil && !vm.TemplateID.Equal(*templateID) {
+			continue
+		}
+		if name := p.Get("name"); name != "" && name != vm.Name {
+			continue
+		}
+		if state := p.Get("state"); state != "" && !strings.EqualFold(state, vm.State) {
+			continue
+		}
+		if keyword := p.Get("keyword"); keyword != "" && !strings.Contains(vm.Name, keyword) && !strings.Contains(vm.DisplayName, keyword) {
+			continue
+		}
+		if networkID != nil && vm.NicByNetworkID(*networkID) == nil {
+			continue
+		}
+		if ipAddress != nil {
+			if nic := vm.DefaultNic(); nic == nil || !nic.IPAddress.Equal(ipAddress) {
+				continue
+			}
+		}
+		if affinityGroupID != nil {
+			found := false
+			for _, ag := range vm.AffinityGroup {
+				found = found || ag.ID.Equal(*affinityGroupID)
+			}
+			if !found {
+				continue
+			}
+		}
+		if !m.matchTags(p, vm.ID) {
+			continue
+		}
+		resp.VirtualMachine = append(resp.VirtualMachine, m.renderVirtualMachine(vm))
+	}
+
+	if id != nil && len(resp.VirtualMachine) == 0 {
+		return nil, mockNotFound("virtual machine", id)
+	}
+
+	start, end := paginate(p, len(resp.VirtualMachine))
+	resp.VirtualMachine = resp.VirtualMachine[start:end]
+	resp.Count = len(resp.VirtualMachine)
+
+	return resp, nil
+}
+
+// publicKey returns the public key of the VM key pair, when the mock knows it
+// the password is encrypted using it.
+func (m *mockComputeAPI) publicKey(vm *egoscale.VirtualMachine) *rsa.PublicKey {
+	key := m.findSSHKeyPair(vm.KeyPair)
+	if key == nil {
+		return nil
+	}
+
+	return m.publicKeys[key.Fingerprint]
+}
+
+func (m *mockComputeAPI) getVMPassword(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if !vm.PasswordEnabled || vm.Password == "" {
+		return nil, mockComputeError(egoscale.ParamError, "No password for VM with specified id found.")
+	}
+
+	encrypted := []byte(vm.Password)
+	if public := m.publicKey(vm); public != nil {
+		encrypted, err = rsa.EncryptPKCS1v15(rand.Reader, public, encrypted)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]interface{}{
+		"password": egoscale.Password{
+			EncryptedPassword: base64.StdEncoding.EncodeToString(encrypted),
+		},
+	}, nil
+}
+
+func (m *mockComputeAPI) getVirtualMachineUserData(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "virtualmachineid")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"virtualmachineuserdata": egoscale.VirtualMachineUserData{
+			UserData:         m.userData[vm.ID.String()],
+			VirtualMachineID: vm.ID,
+		},
+	}, nil
+}
+
+func (m *mockComputeAPI) listVolumes(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+	vmID, err := p.uuid("virtualmachineid")
+	if err != nil {
+		return nil, err
+	}
+	zoneID, err := p.uuid("zoneid")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &egoscale.ListVolumesResponse{}
+	for _, volume := range m.volumes {
+		if id != nil && !volume.ID.Equal(*id) {
+			continue
+		}
+		if vmID != nil && !volume.VirtualMachineID.Equal(*vmID) {
+			continue
+		}
+		if zoneID != nil && !volume.ZoneID.Equal(*zoneID) {
+			continue
+		}
+		if t := p.Get("type"); t != "" && !strings.EqualFold(t, volume.Type) {
+			continue
+		}
+		resp.Volume = append(resp.Volume, *volume)
+	}
+
+	if id != nil && len(resp.Volume) == 0 {
+		return nil, mockNotFound("volume", id)
+	}
+
+	start, end := paginate(p, len(resp.Volume))
+	resp.Volume = resp.Volume[start:end]
+	resp.Count = len(resp.Volume)
+
+	return resp, nil
+}
+
+func (m *mockComputeAPI) resizeVolume(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, volume := range m.volumes {
+		if id == nil || !volume.ID.Equal(*id) {
+			continue
+		}
+
+		size := uint64(p.int64("size")) << 30
+		if size < volume.Size {
+			return nil, mockComputeError(egoscale.ParamError, "Shrinking a volume is not supported")
+		}
+		volume.Size = size
+
+		return map[string]interface{}{"volume": volume}, nil
+	}
+
+	return nil, mockNotFound("volume", p.Get("id"))
+}
+
+func (m *mockComputeAPI) findNetwork(id *egoscale.UUID) *egoscale.Network {
+	if id == nil {
+		return nil
+	}
+
+	for _, network := range m.networks {
+		if network.ID.Equal(*id) {
+			return network
+		}
+	}
+
+	return nil
+}
+
+func (m *mockComputeAPI) renderNetwork(network *egoscale.Network) egoscale.Network {
+	n := *network
+	n.Tags = m.resourceTags(network.ID)
+
+	return n
+}
+
+func (m *mockComputeAPI) createNetwork(p mockParams) (interface{}, error) {
+	zone, err := m.findZone(p)
+	if err != nil {
+		return nil, err
+	}
+
+	offeringID, err := p.uuid("networkofferingid")
+	if err != nil {
+		return nil, err
+	}
+
+	var offering *egoscale.NetworkOffering
+	for _, o := range m.networkOfferings {
+		if offeringID != nil && o.ID.Equal(*offeringID) {
+			offering = o
+		}
+	}
+	if offering == nil {
+		return nil, mockNotFound("network offering", p.Get("networkofferingid"))
+	}
+
+	startIP, endIP, netmask := p.ip("startip"), p.ip("endip"), p.ip("netmask")
+	if (startIP == nil) != (endIP == nil) || (startIP != nil && netmask == nil) {
+		return nil, mockComputeError(egoscale.ParamError, "startip, endip and netmask must be specified together")
+	}
+
+	network := &egoscale.Network{
+		CanUseForDeploy:     true,
+		DisplayText:         p.Get("displaytext"),
+		EndIP:               endIP,
+		ID:                  m.newUUID(),
+		Name:                p.Get("name"),
+		Netmask:             netmask,
+		NetworkOfferingID:   offering.ID,
+		NetworkOfferingName: offering.Name,
+		StartIP:             startIP,
+		State:               "Implemented",
+		TrafficType:         "Guest",
+		Type:                "Isolated",
+		ZoneID:              zone.ID,
+		ZoneName:            zone.Name,
+	}
+	m.networks = append(m.networks, network)
+
+	return map[string]interface{}{"network": m.renderNetwork(network)}, nil
+}
+
+func (m *mockComputeAPI) updateNetwork(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	network := m.findNetwork(id)
+	if network == nil {
+		return nil, mockNotFound("network", p.Get("id"))
+	}
+
+	if _, ok := p.Values["name"]; ok {
+		network.Name = p.Get("name")
+	}
+	if _, ok := p.Values["displaytext"]; ok {
+		network.DisplayText = p.Get("displaytext")
+	}
+	if ip := p.ip("startip"); ip != nil {
+		network.StartIP = ip
+	}
+	if ip := p.ip("endip"); ip != nil {
+		network.EndIP = ip
+	}
+	if ip := p.ip("netmask"); ip != nil {
+		network.Netmask = ip
+	}
+
+	return map[string]interface{}{"network": m.renderNetwork(network)}, nil
+}
+
+func (m *mockComputeAPI) deleteNetwork(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	network := m.findNetwork(id)
+	if network == nil {
+		return nil, mockNotFound("network", p.Get("id"))
+	}
+
+	for _, vm := range m.virtualMachines {
+		if vm.NicByNetworkID(*network.ID) != nil {
+			return nil, mockComputeError(egoscale.ResourceInUseError, "Unable to delete network %s, virtual machines are still attached", id)
+		}
+	}
+
+	networks := m.networks[:0]
+	for _, n := range m.networks {
+		if n != network {
+			networks = append(networks, n)
+		}
+	}
+	m.networks = networks
+	delete(m.tags, id.String())
+
+	return mockSuccess(), nil
+}
+
+func (m *mockComputeAPI) listNetworks(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+	zoneID, err := p.uuid("zoneid")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &egoscale.ListNetworksResponse{}
+	for _, network := range m.networks {
+		if id != nil && !network.ID.Equal(*id) {
+			continue
+		}
+		if zoneID != nil && !network.ZoneID.Equal(*zoneID) {
+			continue
+		}
+		if keyword := p.Get("keyword"); keyword != "" && !strings.Contains(network.Name, keyword) {
+			continue
+		}
+		if !m.matchTags(p, network.ID) {
+			continue
+		}
+		resp.Network = append(resp.Network, m.renderNetwork(network))
+	}
+
+	if id != nil && len(resp.Network) == 0 {
+		return nil, mockNotFound("network", id)
+	}
+
+	start, end := paginate(p, len(resp.Network))
+	resp.Network = resp.Network[start:end]
+	resp.Count = len(resp.Network)
+
+	return resp, nil
+}
+
+// findNic returns the VM owning the NIC and the NIC itself.
+func (m *mockComputeAPI) findNic(id *egoscale.UUID) (*egoscale.VirtualMachine, *egoscale.Nic) {
+	if id == nil {
+		return nil, nil
+	}
+
+	for _, vm := range m.virtualMachines {
+		for i := range vm.Nic {
+			if vm.Nic[i].ID.Equal(*id) {
+				return vm, &vm.Nic[i]
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func (m *mockComputeAPI) listNics(p mockParams) (interface{}, error) {
+	nicID, err := p.uuid("nicid")
+	if err != nil {
+		return nil, err
+	}
+	networkID, err := p.uuid("networkid")
+	if err != nil {
+		return nil, err
+	}
+
+	vms := m.virtualMachines
+	if p.Get("virtualmachineid") != "" {
+		vm, err := m.virtualMachine(p, "virtualmachineid")
+		if err != nil {
+			return nil, err
+		}
+		vms = []*egoscale.VirtualMachine{vm}
+	} else if nicID == nil {
+		return nil, mockComputeError(egoscale.ParamError, "Unable to execute API command listnics due to missing parameter virtualmachineid")
+	}
+
+	resp := &egoscale.ListNicsResponse{}
+	for _, vm := range vms {
+		for _, nic := range vm.Nic {
+			if nicID != nil && !nic.ID.Equal(*nicID) {
+				continue
+			}
+			if networkID != nil && !nic.NetworkID.Equal(*networkID) {
+				continue
+			}
+			resp.Nic = append(resp.Nic, nic)
+		}
+	}
+
+	start, end := paginate(p, len(resp.Nic))
+	resp.Nic = resp.Nic[start:end]
+	resp.Count = len(resp.Nic)
+
+	return resp, nil
+}
+
+func (m *mockComputeAPI) addNicToVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "virtualmachineid")
+	if err != nil {
+		return nil, err
+	}
+
+	networkID, err := p.uuid("networkid")
+	if err != nil {
+		return nil, err
+	}
+
+	network := m.findNetwork(networkID)
+	if network == nil {
+		return nil, mockNotFound("network", p.Get("networkid"))
+	}
+
+	if vm.NicByNetworkID(*network.ID) != nil {
+		return nil, mockComputeError(egoscale.ParamError, "A NIC already exists for VM %s in network %s", vm.ID, network.ID)
+	}
+
+	vm.Nic = append(vm.Nic, m.newIsolatedNic(vm.ID, network, p.ip("ipaddress")))
+
+	return m.virtualMachineResponse(vm), nil
+}
+
+// newIsolatedNic plugs a virtual machine into a private network
+func (m *mockComputeAPI) newIsolatedNic(vmID *egoscale.UUID, network *egoscale.Network, ip net.IP) egoscale.Nic {
+	return egoscale.Nic{
+		ID:               m.newUUID(),
+		IPAddress:        ip,
+		MACAddress:       m.newMAC(),
+		Netmask:          network.Netmask,
+		NetworkID:        network.ID,
+		NetworkName:      network.Name,
+		TrafficType:      "Guest",
+		Type:             "Isolated",
+		VirtualMachineID: vmID,
+	}
+}
+
+func (m *mockComputeAPI) removeNicFromVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "virtualmachineid")
+	if err != nil {
+		return nil, err
+	}
+
+	nicID, err := p.uuid("nicid")
+	if err != nil {
+		return nil, err
+	}
+
+	for i, nic := range vm.Nic {
+		if nicID != nil && nic.ID.Equal(*nicID) {
+			if nic.IsDefault {
+				return nil, mockComputeError(egoscale.ParamError, "Unable to remove the default NIC of VM %s", vm.ID)
+			}
+			vm.Nic = append(vm.Nic[:i], vm.Nic[i+1:]...)
+			return m.virtualMachineResponse(vm), nil
+		}
+	}
+
+	return nil, mockNotFound("nic", p.Get("nicid"))
+}
+
+func (m *mockComputeAPI) updateDefaultNicForVirtualMachine(p mockParams) (interface{}, error) {
+	vm, err := m.virtualMachine(p, "virtualmachineid")
+	if err != nil {
+		return nil, err
+	}
+
+	nicID, err := p.uuid("nicid")
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	for _, nic := range vm.Nic {
+		if nicID != nil && nic.ID.Equal(*nicID) {
+			found = true
+		}
+	}
+	if !found {
+		return nil, mockNotFound("nic", p.Get("nicid"))
+	}
+
+	for i := range vm.Nic {
+		vm.Nic[i].IsDefault = vm.Nic[i].ID.Equal(*nicID)
+	}
+
+	return m.virtualMachineResponse(vm), nil
+}
+
+func (m *mockComputeAPI) updateVMNicIP(p mockParams) (interface{}, error) {
+	nicID, err := p.uuid("nicid")
+	if err != nil {
+		return nil, err
+	}
+
+	vm, nic := m.findNic(nicID)
+	if nic == nil {
+		return nil, mockNotFound("nic", p.Get("nicid"))
+	}
+
+	if nic.Type != "Isolated" {
+		return nil, mockComputeError(egoscale.ParamError, "Only the IP address of a private network NIC can be updated")
+	}
+
+	nic.IPAddress = p.ip("ipaddress")
+
+	return m.virtualMachineResponse(vm), nil
+}
+
+func (m *mockComputeAPI) addIPToNic(p mockParams) (interface{}, error) {
+	nicID, err := p.uuid("nicid")
+	if err != nil {
+		return nil, err
+	}
+
+	vm, nic := m.findNic(nicID)
+	if nic == nil {
+		return nil, mockNotFound("nic", p.Get("nicid"))
+	}
+
+	ip := p.ip("ipaddress")
+	if ip == nil {
+		return nil, mockComputeError(egoscale.ParamError, "Unable to execute API command addiptonic due to missing parameter ipaddress")
+	}
+
+	for _, secondary := range nic.SecondaryIP {
+		if secondary.IPAddress.Equal(ip) {
+			return nil, mockComputeError(egoscale.ParamError, "IP address %s is already assigned to the NIC", ip)
+		}
+	}
+
+	secondary := egoscale.NicSecondaryIP{
+		ID:               m.newUUID(),
+		IPAddress:        ip,
+		NetworkID:        nic.NetworkID,
+		NicID:            nic.ID,
+		VirtualMachineID: vm.ID,
+	}
+	nic.SecondaryIP = append(nic.SecondaryIP, secondary)
+
+	return map[string]interface{}{"nicsecondaryip": secondary}, nil
+}
+
+func (m *mockComputeAPI) removeIPFromNic(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vm := range m.virtualMachines {
+		for i := range vm.Nic {
+			nic := &vm.Nic[i]
+			for j, secondary := range nic.SecondaryIP {
+				if id != nil && secondary.ID.Equal(*id) {
+					nic.SecondaryIP = append(nic.SecondaryIP[:j], nic.SecondaryIP[j+1:]...)
+					return mockSuccess(), nil
+				}
+			}
+		}
+	}
+
+	return nil, mockNotFound("secondary ip", p.Get("id"))
+}
+
+func (m *mockComputeAPI) activateNicIPv6(nic *egoscale.Nic) {
+	n := m.next()
+	nic.IP6Address = net.ParseIP(fmt.Sprintf("2a04:c43:e00:6bcf::%x", n))
+	nic.IP6CIDR = egoscale.MustParseCIDR("2a04:c43:e00:6bcf::/64")
+	nic.IP6Gateway = net.ParseIP("2a04:c43:e00:6bcf::1")
+}
+
+func (m *mockComputeAPI) activateIP6(p mockParams) (interface{}, error) {
+	nicID, err := p.uuid("nicid")
+	if err != nil {
+		return nil, err
+	}
+
+	_, nic := m.findNic(nicID)
+	if nic == nil {
+		return nil, mockNotFound("nic", p.Get("nicid"))
+	}
+
+	if nic.IP6Address == nil {
+		m.activateNicIPv6(nic)
+	}
+
+	return map[string]interface{}{"nic": nic}, nil
+}
+
+func (m *mockComputeAPI) findIPAddress(id *egoscale.UUID) *egoscale.IPAddress {
+	if id == nil {
+		return nil
+	}
+
+	for _, ip := range m.ipAddresses {
+		if ip.ID.Equal(*id) {
+			return ip
+		}
+	}
+
+	return nil
+}
+
+func (m *mockComputeAPI) renderIPAddress(ip *egoscale.IPAddress) egoscale.IPAddress {
+	address := *ip
+	address.Tags = m.resourceTags(ip.ID)
+	if ip.Healthcheck != nil {
+		healthcheck := *ip.Healthcheck
+		address.Healthcheck = &healthcheck
+	}
+
+	return address
+}
+
+// applyHealthcheck updates the healthcheck of an IP address with the given parameters.
+func (m *mockComputeAPI) applyHealthcheck(ip *egoscale.IPAddress, p mockParams) error {
+	// An empty mode disables the healthcheck
+	if mode, ok := p.Values["mode"]; ok && len(mode) == 1 && mode[0] == "" {
+		ip.Healthcheck = nil
+		return nil
+	}
+
+	healthcheck := egoscale.Healthcheck{}
+	if ip.Healthcheck != nil {
+		healthcheck = *ip.Healthcheck
+	}
+
+	if mode := p.Get("mode"); mode != "" {
+		if mode != "tcp" && mode != "http" {
+			return mockComputeError(egoscale.ParamError, "Invalid healthcheck mode %q", mode)
+		}
+		healthcheck.Mode = mode
+	}
+
+	for key, field := range map[string]*int64{
+		"port":         &healthcheck.Port,
+		"interval":     &healthcheck.Interval,
+		"timeout":      &healthcheck.Timeout,
+		"strikes-ok":   &healthcheck.StrikesOk,
+		"strikes-fail": &healthcheck.StrikesFail,
+	} {
+		if _, ok := p.Values[key]; ok {
+			*field = p.int64(key)
+		}
+	}
+	if _, ok := p.Values["path"]; ok {
+		healthcheck.Path = p.Get("path")
+	}
+
+	if healthcheck.Mode == "" {
+		if healthcheck != (egoscale.Healthcheck{}) {
+			return mockComputeError(egoscale.ParamError, "A healthcheck mode is required")
+		}
+		return nil
+	}
+
+	if healthcheck.Interval == 0 {
+		healthcheck.Interval = 10
+	}
+	if healthcheck.Timeout == 0 {
+		healthcheck.Timeout = 2
+	}
+	if healthcheck.StrikesOk == 0 {
+		healthcheck.StrikesOk = 3
+	}
+	if healthcheck.StrikesFail == 0 {
+		healthcheck.StrikesFail = 3
+	}
+	if healthcheck.Mode == "http" && healthcheck.Path == "" {
+		healthcheck.Path = "/"
+	}
+	if healthcheck.Timeout >= healthcheck.Interval {
+		return mockComputeError(egoscale.ParamError, "The healthcheck timeout must be lower than its interval")
+	}
+
+	ip.Healthcheck = &healthcheck
+
+	return nil
+}
+
+func (m *mockComputeAPI) associateIPAddress(p mockParams) (interface{}, error) {
+	zone, err := m.findZone(p)
+	if err != nil {
+		return nil, err
+	}
+
+	ip := &egoscale.IPAddress{
+		Allocated: time.Now().Format("2006-01-02T15:04:05-0700"),
+		ID:        m.newUUID(),
+		IPAddress: m.newIPv4(159),
+		IsElastic: true,
+		State:     "Allocated",
+		ZoneID:    zone.ID,
+		ZoneName:  zone.Name,
+	}
+
+	if err := m.applyHealthcheck(ip, p); err != nil {
+		return nil, err
+	}
+	m.ipAddresses = append(m.ipAddresses, ip)
+
+	return map[string]interface{}{"ipaddress": m.renderIPAddress(ip)}, nil
+}
+
+func (m *mockComputeAPI) disassociateIPAddress(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	ip := m.findIPAddress(id)
+	if ip == nil {
+		return nil, mockNotFound("ip address", p.Get("id"))
+	}
+
+	addresses := m.ipAddresses[:0]
+	for _, address := range m.ipAddresses {
+		if address != ip {
+			addresses = append(addresses, address)
+		}
+	}
+	m.ipAddresses = addresses
+	delete(m.tags, id.String())
+
+	return mockSuccess(), nil
+}
+
+func (m *mockComputeAPI) updateIPAddress(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	ip := m.findIPAddress(id)
+	if ip == nil {
+		return nil, mockNotFound("ip address", p.Get("id"))
+	}
+
+	if err := m.applyHealthcheck(ip, p); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"ipaddress": m.renderIPAddress(ip)}, nil
+}
+
+// publicIPAddress finds the public IP address given by the id parameter.
+func (m *mockComputeAPI) publicIPAddress(p mockParams) (*egoscale.IPAddress, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+
+	ip := m.findIPAddress(id)
+	if ip == nil {
+		return nil, mockNotFound("ip address", p.Get("id"))
+	}
+
+	return ip, nil
+}
+
+func (m *mockComputeAPI) renderIPAddressReverseDNS(ip *egoscale.IPAddress) egoscale.IPAddress {
+	address := m.renderIPAddress(ip)
+	address.ReverseDNS = nil
+	if domainName, ok := m.reverseDNS[ip.ID.String()]; ok {
+		address.ReverseDNS = []egoscale.ReverseDNS{{
+			DomainName: domainName,
+			IPAddress:  ip.IPAddress,
+			PublicIPID: ip.ID,
+		}}
+	}
+
+	return address
+}
+
+func (m *mockComputeAPI) updateReverseDNSForPublicIPAddress(p mockParams) (interface{}, error) {
+	ip, err := m.publicIPAddress(p)
+	if err != nil {
+		return nil, err
+	}
+
+	domainName, err := mockDomainName(p)
+	if err != nil {
+		return nil, err
+	}
+	m.reverseDNS[ip.ID.String()] = domainName
+
+	return map[string]interface{}{"ipaddress": m.renderIPAddressReverseDNS(ip)}, nil
+}
+
+func (m *mockComputeAPI) queryReverseDNSForPublicIPAddress(p mockParams) (interface{}, error) {
+	ip, err := m.publicIPAddress(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"ipaddress": m.renderIPAddressReverseDNS(ip)}, nil
+}
+
+func (m *mockComputeAPI) deleteReverseDNSFromPublicIPAddress(p mockParams) (interface{}, error) {
+	ip, err := m.publicIPAddress(p)
+	if err != nil {
+		return nil, err
+	}
+	delete(m.reverseDNS, ip.ID.String())
+
+	return mockSuccess(), nil
+}
+
+func (m *mockComputeAPI) listPublicIPAddresses(p mockParams) (interface{}, error) {
+	id, err := p.uuid("id")
+	if err != nil {
+		return nil, err
+	}
+	zoneID, err := p.uuid("zoneid")
+	if err != nil {
+		return nil, err
+	}
+	ipAddress := p.ip("ipaddress")
+	isElastic := p.bool("iselastic")
+
+	resp := &egoscale.ListPublicIPAddressesResponse{}
+	for _, ip := range m.ipAddresses {
+		if id != nil && !ip.ID.Equal(*id) {
+			continue
+		}
+		if zoneID != nil && !ip.ZoneID.Equal(*zoneID) {
+			continue
+		}
+		if ipAddress != nil && !ip.IPAddress.Equal(ipAddress) {
+			continue
+		}
+		if isElastic != nil && *isElastic != ip.IsElastic {
+			continue
+		}
+		if !m.matchTags(p, ip.ID) {
+			continue
+		}
+		resp.PublicIPAddress = append(resp.PublicIPAddress, m.renderIPAddress(ip))
+	}
+
+	if id != nil && len(resp.PublicIPAddress) == 0 {
+		return nil, mockNotFound("ip address", id)
+	}
+
+	start, end := paginate(p, len(resp.PublicIPAddress))
+	resp.PublicIPAddress = resp.PublicIPAddress[start:end]
+	resp.Count = len(resp.PublicIPAddress)
+
+	return resp, nil
+}
+
+// taggedResource checks that the resource to tag exists.
+func (m *mockComputeAPI) taggedResource(resourceType string, id *egoscale.UUID) error {
+	switch strings.ToLower(resourceType) {
+	case "uservm":
+		if m.findVirtualMachine(id) != nil {
+			return nil
+		}
+	case "network":
+		if m.findNetwork(id) != nil {
+			return nil
+		}
+	case "publicipaddress":
+		if m.findIPAddress(id) != nil {
+			return nil
+		}
+	default:
+		return mockComputeError(egoscale.ParamError, "Unsupported resource type %s", resourceType)
+	}
+
+	return mockNotFound(resourceType, id)
+}
+
+func (m *mockComputeAPI) createTags(p mockParams) (interface{}, error) {
+	ids, err := p.uuids("resourceids")
+	if err != nil {
+		return nil, err
+	}
+
+	resourceType := p.Get("resourcetype")
+	tags := p.list("tags")
+	if len(ids) == 0 || len(tags) == 0 {
+		return nil, mockComputeError(egoscale.ParamError, "Unable to execute API command createtags due to missing parameter")
+	}
+
+	for i := range ids {
+		if err := m.taggedResource(resourceType, &ids[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range ids {
+		id := ids[i]
+		for _, tag := range tags {
+			for _, existing := range m.tags[id.String()] {
+				if existing.Key == tag["key"] {
+					return nil, mockComputeError(egoscale.ParamError, "tag %s already on UserVm with id %s", tag["key"], id)
+				}
+			}
+
+			m.tags[id.String()] = append(m.tags[id.String()], egoscale.ResourceTag{
+				Key:          tag["key"],
+				ResourceID:   &id,
+				ResourceType: resourceType,
+				Value:        tag["value"],
+			})
+		}
+	}
+
+	return mockSuccess(), nil
+}
+
+func (m *mockComputeAPI) deleteTags(p mockParams) (interface{}, error) {
+	ids, err := p.uuids("resourceids")
+	if err != nil {
+		return nil, err
+	}
+
+	tags := p.list("tags")
+	for i := range ids {
+		id := ids[i].String()
+		kept := []egoscale.ResourceTag{}
+		for _, existing := range m.tags[id] {
+			remove := len(tags) == 0
+			for _, tag := range tags {
+				if existing.Key == tag["key"] && (tag["value"] == "" || existing.Value == tag["value"]) {
+					remove = true
+				}
+			}
+			if !remove {
+				kept = append(kept, existing)
+			}
+		}
+		m.tags[id] = kept
+	}
+
+	return mockSuccess(), nil
+}
+
+func (m *mockComputeAPI) listTags(p mockParams) (interface{}, error) {
+	resourceID, err := p.uuid("resourceid")
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(m.tags))
+	for id := range m.tags {
+		keys = append(keys, id)
+	}
+	sort.Strings(keys)
+
+	resp := &egoscale.ListTagsResponse{}
+	for _, id := range keys {
+		for _, tag := range m.tags[id] {
+			if resourceID != nil && !tag.ResourceID.Equal(*resourceID) {
+				continue
+			}
+			if t := p.Get("resourcetype"); t != "" && !strings.EqualFold(t, tag.ResourceType) {
+				continue
+			}
+			if key := p.Get("key"); key != "" && key != tag.Key {
+				continue
+			}
+			if value := p.Get("value"); value != "" && value != tag.Value {
+				continue
+			}
+			resp.Tag = append(resp.Tag, tag)
+		}
+	}
+
+	start, end := paginate(p, len(resp.Tag))
+	resp.Tag = resp.Tag[start:end]
+	resp.Count = len(resp.Tag)
+
+	return resp, nil
+}
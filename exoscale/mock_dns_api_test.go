@@ -0,0 +1,480 @@
+package exoscale
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/exoscale/egoscale"
+)
+
+// mockDNSAPIPrefix is the path under which the mock serves the DNS API, the
+// DNS endpoint given to the provider is the server URL followed by it.
+const mockDNSAPIPrefix = "/dns"
+
+// mockDNSDefaultTTL is the TTL given to records created without one.
+const mockDNSDefaultTTL = 3600
+
+// mockDNSNameservers are the NS records added to every new domain.
+var mockDNSNameservers = []string{
+	"ns1.exoscale.ch",
+	"ns1.exoscale.com",
+	"ns1.exoscale.io",
+	"ns1.exoscale.net",
+}
+
+var mockDNSDomainName = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+[a-z][a-z0-9-]*[a-z0-9]$`)
+
+// mockDNSAPI is an in-process, stateful stand-in for the Exoscale DNS v1 API.
+type mockDNSAPI struct {
+	*httptest.Server
+
+	token string
+
+	mu       sync.Mutex
+	sequence int64
+	domains  []*egoscale.DNSDomain
+	records  map[int64][]*egoscale.DNSRecord
+}
+
+// newMockDNSAPI starts a mock DNS API accepting the given credentials.
+func newMockDNSAPI(key, secret string) *mockDNSAPI {
+	m := &mockDNSAPI{
+		token:   key + ":" + secret,
+		records: make(map[int64][]*egoscale.DNSRecord),
+	}
+
+	m.Server = httptest.NewServer(m)
+
+	return m
+}
+
+// Endpoint returns the value to use as the provider dns_endpoint.
+func (m *mockDNSAPI) Endpoint() string {
+	return m.URL + mockDNSAPIPrefix
+}
+
+func (m *mockDNSAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-DNS-TOKEN") != m.token {
+		m.writeError(w, http.StatusUnauthorized, &egoscale.DNSErrorResponse{
+			Message: "Authentication failed",
+		})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, mockDNSAPIPrefix+"/v1/domains")
+	if path == r.URL.Path {
+		m.writeError(w, http.StatusNotFound, &egoscale.DNSErrorResponse{
+			Message: "Not found",
+		})
+		return
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if parts[0] == "" {
+		parts = nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		status = http.StatusOK
+		body   interface{}
+		err    error
+	)
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		body = m.listDomains()
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		status = http.StatusCreated
+		body, err = m.createDomain(r)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		body, err = m.getDomain(parts[0])
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		body, err = m.deleteDomain(parts[0])
+	case len(parts) == 2 && parts[1] == "records" && r.Method == http.MethodGet:
+		body, err = m.listRecords(parts[0], r)
+	case len(parts) == 2 && parts[1] == "records" && r.Method == http.MethodPost:
+		status = http.StatusCreated
+		body, err = m.createRecord(parts[0], r)
+	case len(parts) == 3 && parts[1] == "records" && r.Method == http.MethodGet:
+		body, err = m.getRecord(parts[0], parts[2])
+	case len(parts) == 3 && parts[1] == "records" && r.Method == http.MethodPut:
+		body, err = m.updateRecord(parts[0], parts[2], r)
+	case len(parts) == 3 && parts[1] == "records" && r.Method == http.MethodDelete:
+		body, err = m.deleteRecord(parts[0], parts[2])
+	default:
+		err = &mockDNSError{http.StatusNotFound, &egoscale.DNSErrorResponse{Message: "Not found"}}
+	}
+
+	if err != nil {
+		if e, ok := err.(*mockDNSError); ok {
+			m.writeError(w, e.status, e.response)
+			return
+		}
+		m.writeError(w, http.StatusInternalServerError, &egoscale.DNSErrorResponse{Message: err.Error()})
+		return
+	}
+
+	m.writeJSON(w, status, body)
+}
+
+// mockDNSError carries an error response along with its HTTP status.
+type mockDNSError struct {
+	status   int
+	response *egoscale.DNSErrorResponse
+}
+
+func (e *mockDNSError) Error() string {
+	return e.response.Error()
+}
+
+func mockDNSNotFound(kind string) error {
+	return &mockDNSError{http.StatusNotFound, &egoscale.DNSErrorResponse{
+		Message: kind + " not found",
+	}}
+}
+
+func mockDNSValidationError(field string, messages ...string) error {
+	return &mockDNSError{http.StatusUnprocessableEntity, &egoscale.DNSErrorResponse{
+		Message: "Validation failed",
+		Errors:  map[string][]string{field: messages},
+	}}
+}
+
+func (m *mockDNSAPI) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) // nolint: errcheck
+}
+
+func (m *mockDNSAPI) writeError(w http.ResponseWriter, status int, e *egoscale.DNSErrorResponse) {
+	m.writeJSON(w, status, e)
+}
+
+func (m *mockDNSAPI) now() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// findDomain looks a domain up by name or by identifier.
+func (m *mockDNSAPI) findDomain(ref string) (*egoscale.DNSDomain, error) {
+	id, _ := strconv.ParseInt(ref, 10, 64)
+	for _, domain := range m.domains {
+		if domain.Name == strings.ToLower(ref) || domain.ID == id {
+			return domain, nil
+		}
+	}
+
+	return nil, mockDNSNotFound("Domain")
+}
+
+func (m *mockDNSAPI) renderDomain(domain *egoscale.DNSDomain) egoscale.DNSDomainResponse {
+	d := *domain
+	d.RecordCount = int64(len(m.records[domain.ID]))
+
+	return egoscale.DNSDomainResponse{Domain: &d}
+}
+
+func (m *mockDNSAPI) listDomains() []egoscale.DNSDomainResponse {
+	domains := make([]egoscale.DNSDomainResponse, 0, len(m.domains))
+	for _, domain := range m.domains {
+		domains = append(domains, m.renderDomain(domain))
+	}
+
+	return domains
+}
+
+func (m *mockDNSAPI) createDomain(r *http.Request) (interface{}, error) {
+	var req egoscale.DNSDomainResponse
+	if err := decodeMockDNSBody(r, &req); err != nil {
+		return nil, err
+	}
+
+	if req.Domain == nil || req.Domain.Name == "" {
+		return nil, mockDNSValidationError("name", "can't be blank")
+	}
+
+	name := strings.ToLower(req.Domain.Name)
+	if !mockDNSDomainName.MatchString(name) {
+		return nil, mockDNSValidationError("name", "is an invalid domain name")
+	}
+
+	if _, err := m.findDomain(name); err == nil {
+		return nil, mockDNSValidationError("name", "has already been taken")
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	m.sequence++
+	now := m.now()
+	domain := &egoscale.DNSDomain{
+		ID:          m.sequence,
+		Name:        name,
+		UnicodeName: name,
+		Token:       hex.EncodeToString(token),
+		State:       "hosted",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	m.domains = append(m.domains, domain)
+
+	m.addRecord(domain, egoscale.DNSRecord{
+		RecordType: "SOA",
+		Content:    fmt.Sprintf("%s admin.exoscale.ch 1 86400 7200 604800 300", mockDNSNameservers[0]),
+	})
+	for _, ns := range mockDNSNameservers {
+		m.addRecord(domain, egoscale.DNSRecord{
+			RecordType: "NS",
+			Content:    ns,
+		})
+	}
+
+	return m.renderDomain(domain), nil
+}
+
+func (m *mockDNSAPI) getDomain(ref string) (interface{}, error) {
+	domain, err := m.findDomain(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.renderDomain(domain), nil
+}
+
+func (m *mockDNSAPI) deleteDomain(ref string) (interface{}, error) {
+	domain, err := m.findDomain(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	domains := m.domains[:0]
+	for _, d := range m.domains {
+		if d != domain {
+			domains = append(domains, d)
+		}
+	}
+	m.domains = domains
+	delete(m.records, domain.ID)
+
+	return map[string]interface{}{}, nil
+}
+
+// addRecord stores a new record, filling in the server-side fields.
+func (m *mockDNSAPI) addRecord(domain *egoscale.DNSDomain, record egoscale.DNSRecord) *egoscale.DNSRecord {
+	m.sequence++
+	now := m.now()
+
+	record.ID = m.sequence
+	record.DomainID = domain.ID
+	record.CreatedAt = now
+	record.UpdatedAt = now
+	if record.TTL == 0 {
+		record.TTL = mockDNSDefaultTTL
+	}
+
+	m.records[domain.ID] = append(m.records[domain.ID], &record)
+
+	return &record
+}
+
+func (m *mockDNSAPI) findRecord(domain *egoscale.DNSDomain, ref string) (*egoscale.DNSRecord, error) {
+	id, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil {
+		return nil, mockDNSNotFound("Record")
+	}
+
+	for _, record := range m.records[domain.ID] {
+		if record.ID == id {
+			return record, nil
+		}
+	}
+
+	return nil, mockDNSNotFound("Record")
+}
+
+// validateMockDNSRecord applies the checks done by the API on record contents.
+func validateMockDNSRecord(record egoscale.DNSRecord) error {
+	if record.Content == "" {
+		return mockDNSValidationError("content", "can't be blank")
+	}
+
+	found := false
+	for _, t := range supportedRecordTypes {
+		found = found || t == record.RecordType
+	}
+	if !found {
+		return mockDNSValidationError("record_type", "is not included in the list")
+	}
+
+	if record.TTL < 0 {
+		return mockDNSValidationError("ttl", "must be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (m *mockDNSAPI) listRecords(ref string, r *http.Request) (interface{}, error) {
+	domain, err := m.findDomain(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	records := []egoscale.DNSRecordResponse{}
+	for _, record := range m.records[domain.ID] {
+		if name, ok := query["name"]; ok && name[0] != record.Name {
+			continue
+		}
+		if t := query.Get("record_type"); t != "" && !strings.EqualFold(t, record.RecordType) {
+			continue
+		}
+		records = append(records, egoscale.DNSRecordResponse{Record: *record})
+	}
+
+	return records, nil
+}
+
+func (m *mockDNSAPI) createRecord(ref string, r *http.Request) (interface{}, error) {
+	domain, err := m.findDomain(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var req egoscale.DNSRecordResponse
+	if err := decodeMockDNSBody(r, &req); err != nil {
+		return nil, err
+	}
+
+	req.Record.RecordType = strings.ToUpper(req.Record.RecordType)
+	if err := validateMockDNSRecord(req.Record); err != nil {
+		return nil, err
+	}
+
+	record := m.addRecord(domain, egoscale.DNSRecord{
+		Name:       req.Record.Name,
+		RecordType: req.Record.RecordType,
+		Content:    req.Record.Content,
+		TTL:        req.Record.TTL,
+		Prio:       req.Record.Prio,
+	})
+	domain.UpdatedAt = record.UpdatedAt
+
+	return egoscale.DNSRecordResponse{Record: *record}, nil
+}
+
+func (m *mockDNSAPI) getRecord(domainRef, ref string) (interface{}, error) {
+	domain, err := m.findDomain(domainRef)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := m.findRecord(domain, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return egoscale.DNSRecordResponse{Record: *record}, nil
+}
+
+func (m *mockDNSAPI) updateRecord(domainRef, ref string, r *http.Request) (interface{}, error) {
+	domain, err := m.findDomain(domainRef)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := m.findRecord(domain, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var req egoscale.UpdateDNSRecordResponse
+	if err := decodeMockDNSBody(r, &req); err != nil {
+		return nil, err
+	}
+
+	updated := *record
+	if req.Record.Name != "" {
+		updated.Name = req.Record.Name
+	}
+	if req.Record.Content != "" {
+		updated.Content = req.Record.Content
+	}
+	if req.Record.TTL != 0 {
+		updated.TTL = req.Record.TTL
+	}
+	if req.Record.Prio != 0 {
+		updated.Prio = req.Record.Prio
+	}
+	if err := validateMockDNSRecord(updated); err != nil {
+		return nil, err
+	}
+
+	updated.UpdatedAt = m.now()
+	*record = updated
+
+	return egoscale.DNSRecordResponse{Record: *record}, nil
+}
+
+func (m *mockDNSAPI) deleteRecord(domainRef, ref string) (interface{}, error) {
+	domain, err := m.findDomain(domainRef)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := m.findRecord(domain, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	records := m.records[domain.ID][:0]
+	for _, r := range m.records[domain.ID] {
+		if r != record {
+			records = append(records, r)
+		}
+	}
+	m.records[domain.ID] = records
+
+	return map[string]interface{}{}, nil
+}
+
+func decodeMockDNSBody(r *http.Request, v interface{}) error {
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return &mockDNSError{http.StatusBadRequest, &egoscale.DNSErrorResponse{
+			Message: fmt.Sprintf("Invalid JSON body: %s", err),
+		}}
+	}
+
+	return nil
+}
+
+// newMockDNSConfig starts a mock DNS API and returns a provider configuration
+// pointing to it, the caller is responsible for closing the mock.
+func newMockDNSConfig() (*mockDNSAPI, *BaseConfig) {
+	key, secret := "EXOmock", "mock-secret"
+	api := newMockDNSAPI(key, secret)
+
+	return api, &BaseConfig{
+		key:         key,
+		secret:      secret,
+		timeout:     defaultTimeout,
+		dnsEndpoint: api.Endpoint(),
+	}
+}
@@ -2,6 +2,7 @@ package exoscale
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/user"
@@ -12,12 +13,13 @@ import (
 	"github.com/exoscale/egoscale"
 	"github.com/go-ini/ini"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/hashicorp/terraform/terraform"
 )
 
 // Provider returns a terraform.ResourceProvider.
 func Provider() terraform.ResourceProvider {
-	return &schema.Provider{
+	provider := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"key": {
 				Type:        schema.TypeString,
@@ -48,6 +50,12 @@ func Provider() terraform.ResourceProvider {
 					"CLOUDSTACK_SECRET_KEY",
 				}, nil),
 			},
+			"credential_process": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Command printing the API key and secret as JSON, run again when they expire",
+				DefaultFunc: schema.EnvDefaultFunc("EXOSCALE_CREDENTIAL_PROCESS", nil),
+			},
 			"config": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -73,6 +81,18 @@ func Provider() terraform.ResourceProvider {
 					"CLOUDSTACK_REGION",
 				}, defaultProfile),
 			},
+			"zone": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Zone of the resources not setting one (by default: the exo CLI account default zone)",
+				DefaultFunc: schema.EnvDefaultFunc("EXOSCALE_ZONE", nil),
+			},
+			"account": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Account name of the exo CLI configuration file ~/.config/exoscale/exoscale.toml (by default: its default account)",
+				DefaultFunc: schema.EnvDefaultFunc("EXOSCALE_ACCOUNT", nil),
+			},
 			"compute_endpoint": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -105,6 +125,60 @@ func Provider() terraform.ResourceProvider {
 					defaultGzipUserData),
 				DefaultFunc: schema.EnvDefaultFunc("EXOSCALE_GZIP_USER_DATA", defaultGzipUserData),
 			},
+			"retry_max_attempts": {
+				Type:     schema.TypeInt,
+				Required: true,
+				Description: fmt.Sprintf(
+					"Maximum number of attempts of a compute API request failing with a transient error (by default: %d)",
+					defaultRetryMaxAttempts),
+				DefaultFunc:  schema.EnvDefaultFunc("EXOSCALE_RETRY_MAX_ATTEMPTS", defaultRetryMaxAttempts),
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"retry_backoff": {
+				Type:     schema.TypeFloat,
+				Required: true,
+				Description: fmt.Sprintf(
+					"Initial delay in seconds between two attempts, doubled on each retry (by default: %.0f)",
+					defaultRetryBackoff.Seconds()),
+				DefaultFunc:  schema.EnvDefaultFunc("EXOSCALE_RETRY_BACKOFF", defaultRetryBackoff.Seconds()),
+				ValidateFunc: ValidateFloatAtLeast(0),
+			},
+			"max_concurrent_requests": {
+				Type:     schema.TypeInt,
+				Required: true,
+				Description: fmt.Sprintf(
+					"Maximum number of API requests in flight at the same time, 0 meaning unlimited (by default: %d)",
+					defaultMaxConcurrentRequests),
+				DefaultFunc:  schema.EnvDefaultFunc("EXOSCALE_MAX_CONCURRENT_REQUESTS", defaultMaxConcurrentRequests),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"requests_per_second": {
+				Type:     schema.TypeFloat,
+				Required: true,
+				Description: fmt.Sprintf(
+					"Maximum number of API requests sent per second, 0 meaning unlimited (by default: %d)",
+					defaultRequestsPerSecond),
+				DefaultFunc:  schema.EnvDefaultFunc("EXOSCALE_REQUESTS_PER_SECOND", float64(defaultRequestsPerSecond)),
+				ValidateFunc: ValidateFloatAtLeast(0),
+			},
+			"default_tags": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Tags set on every taggable resource, overridden by the resources tags",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"tags": {
+							Type:     schema.TypeMap,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+							Description: "Map of tags (key: value)",
+						},
+					},
+				},
+			},
 			"delay": {
 				Type:       schema.TypeInt,
 				Optional:   true,
@@ -114,25 +188,46 @@ func Provider() terraform.ResourceProvider {
 
 		DataSourcesMap: map[string]*schema.Resource{
 			"exoscale_compute_template": datasourceComputeTemplate(),
+			"exoscale_iso":              datasourceISO(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"exoscale_affinity":             resourceAffinity(),
-			"exoscale_compute":              resourceCompute(),
-			"exoscale_domain_record":        resourceDomainRecord(),
-			"exoscale_domain":               resourceDomain(),
-			"exoscale_ipaddress":            resourceIPAddress(),
-			"exoscale_network":              resourceNetwork(),
-			"exoscale_nic":                  resourceNIC(),
-			"exoscale_secondary_ipaddress":  resourceSecondaryIPAddress(),
-			"exoscale_security_group_rule":  resourceSecurityGroupRule(),
-			"exoscale_security_group_rules": resourceSecurityGroupRules(),
-			"exoscale_security_group":       resourceSecurityGroup(),
-			"exoscale_ssh_keypair":          resourceSSHKeypair(),
+			"exoscale_affinity":              resourceAffinity(),
+			"exoscale_compute":               resourceCompute(),
+			"exoscale_domain_record":         resourceDomainRecord(),
+			"exoscale_domain":                resourceDomain(),
+			"exoscale_eip_service":           resourceEIPService(),
+			"exoscale_ipaddress":             resourceIPAddress(),
+			"exoscale_ipaddress_association": resourceIPAddressAssociation(),
+			"exoscale_network":               resourceNetwork(),
+			"exoscale_nic":                   resourceNIC(),
+			"exoscale_secondary_ipaddress":   resourceSecondaryIPAddress(),
+			"exoscale_security_group_rule":   resourceSecurityGroupRule(),
+			"exoscale_security_group_rules":  resourceSecurityGroupRules(),
+			"exoscale_security_group":        resourceSecurityGroup(),
+			"exoscale_ssh_keypair":           resourceSSHKeypair(),
 		},
 
 		ConfigureFunc: providerConfigure,
 	}
+
+	// The resources without a zone fall back on the provider one, changing
+	// it replaces them as if their own zone had been changed.
+	defaultZone := func() (interface{}, error) {
+		if config, ok := provider.Meta().(*BaseConfig); ok && config.zone != "" {
+			return config.zone, nil
+		}
+		return nil, nil
+	}
+
+	for _, name := range []string{"exoscale_compute_template", "exoscale_iso"} {
+		provider.DataSourcesMap[name].Schema["zone"].DefaultFunc = defaultZone
+	}
+	for _, name := range []string{"exoscale_compute", "exoscale_eip_service", "exoscale_ipaddress", "exoscale_network"} {
+		provider.ResourcesMap[name].Schema["zone"].DefaultFunc = defaultZone
+	}
+
+	return provider
 }
 
 func providerConfigure(d *schema.ResourceData) (interface{}, error) {
@@ -140,6 +235,7 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 	secret, secretOK := d.GetOk("secret")
 	endpoint := d.Get("compute_endpoint").(string)
 	dnsEndpoint := d.Get("dns_endpoint").(string)
+	zone := d.Get("zone").(string)
 
 	// deprecation support
 	token, tokenOK := d.GetOk("token")
@@ -148,11 +244,13 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		key = token
 	}
 
+	credentialProcess, credentialProcessOK := d.GetOk("credential_process")
+
 	if keyOK || secretOK {
 		if !keyOK || !secretOK {
 			return nil, fmt.Errorf("key (%#v) and secret (%#v) must be set", key.(string), secret.(string))
 		}
-	} else {
+	} else if !credentialProcessOK {
 		config := d.Get("config").(string)
 		region := d.Get("region")
 
@@ -170,107 +268,239 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 			}
 		}
 
-		// Convert relative path to absolute
-		config, _ = filepath.Abs(config)
-		localConfig, _ := filepath.Abs("cloudstack.ini")
-
-		inis := []string{
-			config,
-			localConfig,
+		exoConfig := ""
+		if strings.HasSuffix(config, ".toml") {
+			exoConfig = config
+		} else if usr != nil {
+			exoConfig = exoConfigFilename(usr.HomeDir)
 		}
 
-		if usr != nil {
-			inis = append(inis, filepath.Join(usr.HomeDir, ".cloudstack.ini"))
-		}
+		var account *exoAccount
+		if accountName, ok := d.GetOk("account"); ok || strings.HasSuffix(config, ".toml") {
+			// An exo CLI account is explicitly wanted
+			exoCfg, err := loadExoConfig(exoConfig)
+			if err != nil {
+				return nil, err
+			}
 
-		// Stops at the first file that exists
-		config = ""
-		for _, i := range inis {
-			if _, err := os.Stat(i); err != nil {
-				continue
+			if account, err = exoCfg.account(accountName.(string)); err != nil {
+				return nil, fmt.Errorf("%s: %s", exoConfig, err)
 			}
-			config = i
-			break
-		}
+		} else {
+			// Convert relative path to absolute
+			config, _ = filepath.Abs(config)
+			localConfig, _ := filepath.Abs("cloudstack.ini")
+
+			inis := []string{
+				config,
+				localConfig,
+			}
+
+			if usr != nil {
+				inis = append(inis, filepath.Join(usr.HomeDir, ".cloudstack.ini"))
+			}
+
+			// Stops at the first file that exists
+			config = ""
+			for _, i := range inis {
+				if _, err := os.Stat(i); err != nil {
+					continue
+				}
+				config = i
+				break
+			}
+
+			if config == "" {
+				// Falls back on the default account of the exo CLI
+				if _, err := os.Stat(exoConfig); exoConfig == "" || err != nil {
+					return nil, fmt.Errorf("key (%s), secret are missing, or config file not found within: %s, %s", key, strings.Join(inis, ", "), exoConfig)
+				}
 
-		if config == "" {
-			return nil, fmt.Errorf("key (%s), secret are missing, or config file not found within: %s", key, strings.Join(inis, ", "))
+				exoCfg, err := loadExoConfig(exoConfig)
+				if err != nil {
+					return nil, err
+				}
+
+				if account, err = exoCfg.account(""); err != nil {
+					return nil, fmt.Errorf("%s: %s", exoConfig, err)
+				}
+			}
 		}
 
-		cfg, err := ini.LoadSources(ini.LoadOptions{IgnoreInlineComment: true}, config)
-		if err != nil {
-			return nil, fmt.Errorf("Config file not loaded: %s", err)
+		if account != nil {
+			key = account.Key
+			secret = account.Secret
+			if zone == "" {
+				zone = account.DefaultZone
+			}
+
+			if account.Endpoint != "" {
+				endpoint = account.Endpoint
+				dnsEndpoint = strings.Replace(endpoint, "/compute", "/dns", 1)
+			}
+
+			if account.DNSEndpoint != "" {
+				dnsEndpoint = account.DNSEndpoint
+			}
+		} else {
+			cfg, err := ini.LoadSources(ini.LoadOptions{IgnoreInlineComment: true}, config)
+			if err != nil {
+				return nil, fmt.Errorf("Config file not loaded: %s", err)
+			}
+
+			section, err := cfg.GetSection(region.(string))
+			if err != nil {
+				sections := strings.Join(cfg.SectionStrings(), ", ")
+				return nil, fmt.Errorf("%s. Existing sections: %s", err, sections)
+			}
+
+			t, err := section.GetKey("key")
+			if err != nil {
+				return nil, err
+			}
+			key = t.String()
+
+			s, err := section.GetKey("secret")
+			if err != nil {
+				return nil, err
+			}
+			secret = s.String()
+
+			e, err := section.GetKey("endpoint")
+			if err == nil {
+				endpoint = e.String()
+				dnsEndpoint = strings.Replace(endpoint, "/compute", "/dns", 1)
+			}
 		}
+	}
 
-		section, err := cfg.GetSection(region.(string))
-		if err != nil {
-			sections := strings.Join(cfg.SectionStrings(), ", ")
-			return nil, fmt.Errorf("%s. Existing sections: %s", err, sections)
+	defaultTags := make(map[string]string)
+	if t, ok := d.GetOk("default_tags.0.tags"); ok {
+		for k, v := range t.(map[string]interface{}) {
+			defaultTags[k] = v.(string)
 		}
+	}
 
-		t, err := section.GetKey("key")
-		if err != nil {
+	baseConfig := &BaseConfig{
+		key:                   key.(string),
+		secret:                secret.(string),
+		timeout:               time.Duration(int64(d.Get("timeout").(float64)) * int64(time.Second)),
+		computeEndpoint:       endpoint,
+		dnsEndpoint:           dnsEndpoint,
+		gzipUserData:          d.Get("gzip_user_data").(bool),
+		retryMaxAttempts:      d.Get("retry_max_attempts").(int),
+		retryBackoff:          time.Duration(d.Get("retry_backoff").(float64) * float64(time.Second)),
+		maxConcurrentRequests: d.Get("max_concurrent_requests").(int),
+		requestsPerSecond:     d.Get("requests_per_second").(float64),
+		defaultTags:           defaultTags,
+		zone:                  zone,
+	}
+
+	if credentialProcessOK && !keyOK {
+		// The process is run again when the credentials expire
+		baseConfig.credentialProcess = credentialProcess.(string)
+		if err := baseConfig.setProcessCredentials(); err != nil {
 			return nil, err
 		}
-		key = t.String()
+	}
+
+	return baseConfig, nil
+}
+
+func getZoneByName(ctx context.Context, client *computeClient, zoneName string) (*egoscale.Zone, error) {
+	if zoneName == "" {
+		return nil, errors.New("zone must be set, either on the resource or on the provider")
+	}
+
+	zoneName = strings.ToLower(zoneName)
+	zone, err := client.cache.get("zone/"+zoneName, func() (interface{}, error) {
+		resp, err := client.RequestWithContext(ctx, &egoscale.ListZones{
+			Name: zoneName,
+		})
 
-		s, err := section.GetKey("secret")
 		if err != nil {
 			return nil, err
 		}
-		secret = s.String()
 
-		e, err := section.GetKey("endpoint")
-		if err == nil {
-			endpoint = e.String()
-			dnsEndpoint = strings.Replace(endpoint, "/compute", "/dns", 1)
+		zones := resp.(*egoscale.ListZonesResponse)
+		if zones.Count == 0 {
+			return nil, fmt.Errorf("Zone not found %s", zoneName)
 		}
-	}
 
-	baseConfig := BaseConfig{
-		key:             key.(string),
-		secret:          secret.(string),
-		timeout:         time.Duration(int64(d.Get("timeout").(float64)) * int64(time.Second)),
-		computeEndpoint: endpoint,
-		dnsEndpoint:     dnsEndpoint,
-		gzipUserData:    d.Get("gzip_user_data").(bool),
+		return &(zones.Zone[0]), nil
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
-	return baseConfig, nil
+	return zone.(*egoscale.Zone), nil
 }
 
-func getZoneByName(ctx context.Context, client *egoscale.Client, zoneName string) (*egoscale.Zone, error) {
-	resp, err := client.RequestWithContext(ctx, &egoscale.ListZones{
-		Name: strings.ToLower(zoneName),
+func getNetworkOfferingByName(ctx context.Context, client *computeClient, zoneName string) (*egoscale.NetworkOffering, error) {
+	zoneName = strings.ToLower(zoneName)
+	offering, err := client.cache.get("network-offering/"+zoneName, func() (interface{}, error) {
+		resp, err := client.RequestWithContext(ctx, &egoscale.ListNetworkOfferings{
+			Name: zoneName,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		networks := resp.(*egoscale.ListNetworkOfferingsResponse)
+		if networks.Count == 0 {
+			return nil, fmt.Errorf("NetworkOffering not found %s", zoneName)
+		}
+
+		return &(networks.NetworkOffering[0]), nil
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	zones := resp.(*egoscale.ListZonesResponse)
-	if zones.Count == 0 {
-		return nil, fmt.Errorf("Zone not found %s", zoneName)
-	}
-
-	return &(zones.Zone[0]), nil
+	return offering.(*egoscale.NetworkOffering), nil
 }
 
-func getNetworkOfferingByName(ctx context.Context, client *egoscale.Client, zoneName string) (*egoscale.NetworkOffering, error) {
-	resp, err := client.RequestWithContext(ctx, &egoscale.ListNetworkOfferings{
-		Name: strings.ToLower(zoneName),
+// getServiceOfferingsByName returns the service offerings (sizes) matching the name.
+func getServiceOfferingsByName(ctx context.Context, client *computeClient, name string) ([]egoscale.ServiceOffering, error) {
+	offerings, err := client.cache.get("service-offering/"+strings.ToLower(name), func() (interface{}, error) {
+		resp, err := client.RequestWithContext(ctx, &egoscale.ListServiceOfferings{
+			Name: name,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return resp.(*egoscale.ListServiceOfferingsResponse).ServiceOffering, nil
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	networks := resp.(*egoscale.ListNetworkOfferingsResponse)
-	if networks.Count == 0 {
-		return nil, fmt.Errorf("NetworkOffering not found %s", zoneName)
+	return offerings.([]egoscale.ServiceOffering), nil
+}
+
+// getTemplates returns the templates matching the query.
+func getTemplates(ctx context.Context, client *computeClient, req egoscale.ListTemplates) ([]egoscale.Template, error) {
+	key := fmt.Sprintf("template/%s/%s/%s/%s", req.TemplateFilter, req.ZoneID, req.ID, req.Name)
+	templates, err := client.cache.get(key, func() (interface{}, error) {
+		resp, err := client.RequestWithContext(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+
+		return resp.(*egoscale.ListTemplatesResponse).Template, nil
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
-	return &(networks.NetworkOffering[0]), nil
+	return templates.([]egoscale.Template), nil
 }
 
 // handleNotFound inspects the CloudStack ErrorCode to guess if the resource is missing
@@ -1,11 +1,13 @@
 package exoscale
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 )
@@ -23,6 +25,24 @@ func init() {
 	}
 }
 
+// TestMain runs the acceptance tests, or the sweepers when -sweep is given,
+// against in-process mock APIs when EXOSCALE_MOCK_API is set, removing the
+// need for an Exoscale account.
+func TestMain(m *testing.M) {
+	if os.Getenv("EXOSCALE_MOCK_API") != "" {
+		key, secret := "EXOmock", "mock-secret"
+		compute := newMockComputeAPI(key, secret)
+		dns := newMockDNSAPI(key, secret)
+
+		os.Setenv("EXOSCALE_API_KEY", key)                  // nolint: errcheck
+		os.Setenv("EXOSCALE_API_SECRET", secret)            // nolint: errcheck
+		os.Setenv("EXOSCALE_COMPUTE_ENDPOINT", compute.URL) // nolint: errcheck
+		os.Setenv("EXOSCALE_DNS_ENDPOINT", dns.Endpoint())  // nolint: errcheck
+	}
+
+	resource.TestMain(m)
+}
+
 func TestProvider(t *testing.T) {
 	if err := Provider().(*schema.Provider).InternalValidate(); err != nil {
 		t.Fatalf("err: %s", err)
@@ -33,6 +53,48 @@ func TestProvider_impl(t *testing.T) {
 	var _ terraform.ResourceProvider = Provider()
 }
 
+// testPrefix is the name prefix of every resource created by the acceptance
+// tests, the sweepers only destroy resources carrying it.
+const testPrefix = "terraform-test"
+
+// testSweeperConfig builds the provider configuration used by the sweepers.
+func testSweeperConfig() (*BaseConfig, error) {
+	key := os.Getenv("EXOSCALE_API_KEY")
+	secret := os.Getenv("EXOSCALE_API_SECRET")
+	if key == "" || secret == "" {
+		return nil, errors.New("EXOSCALE_API_KEY and EXOSCALE_API_SECRET must be set for sweepers")
+	}
+
+	computeEndpoint := os.Getenv("EXOSCALE_COMPUTE_ENDPOINT")
+	if computeEndpoint == "" {
+		computeEndpoint = defaultComputeEndpoint
+	}
+
+	dnsEndpoint := os.Getenv("EXOSCALE_DNS_ENDPOINT")
+	if dnsEndpoint == "" {
+		dnsEndpoint = defaultDNSEndpoint
+	}
+
+	return &BaseConfig{
+		key:              key,
+		secret:           secret,
+		timeout:          defaultTimeout,
+		computeEndpoint:  computeEndpoint,
+		dnsEndpoint:      dnsEndpoint,
+		retryMaxAttempts: defaultRetryMaxAttempts,
+		retryBackoff:     defaultRetryBackoff,
+	}, nil
+}
+
+// testSweepErrors aggregates the errors met while sweeping resources.
+func testSweepErrors(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("sweeping failed:\n%s", strings.Join(errs, "\n"))
+}
+
 func testAccPreCheck(t *testing.T) {
 	key := os.Getenv("EXOSCALE_API_KEY")
 	secret := os.Getenv("EXOSCALE_API_SECRET")
@@ -107,5 +169,10 @@ func TestCheckResourceAttributes(t *testing.T) {
 }
 
 var defaultExoscaleZone = "ch-gva-2"
+var testAccZone2 = "de-fra-1"
 var defaultExoscaleTemplate = "Linux Ubuntu 18.04 LTS 64-bit"
+var testAccTemplate2 = "Linux Debian 9 64-bit"
+var testAccWindowsTemplate = "Windows Server 2019"
+var testAccISO = "SystemRescueCd"
+var testAccISO2 = "Linux Debian 9 64-bit netinst"
 var defaultExoscaleNetworkOffering = "PrivNet"
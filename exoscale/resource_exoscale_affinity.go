@@ -64,7 +64,10 @@ func resourceAffinityCreate(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	req := &egoscale.CreateAffinityGroup{
 		Name:        d.Get("name").(string),
@@ -89,7 +92,10 @@ func resourceAffinityExists(d *schema.ResourceData, meta interface{}) (bool, err
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return false, err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -112,7 +118,10 @@ func resourceAffinityRead(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -137,7 +146,10 @@ func resourceAffinityDelete(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutDelete))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -1,7 +1,11 @@
 package exoscale
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"testing"
 
 	"github.com/exoscale/egoscale"
@@ -9,6 +13,47 @@ import (
 	"github.com/hashicorp/terraform/terraform"
 )
 
+func init() {
+	resource.AddTestSweepers("exoscale_affinity", &resource.Sweeper{
+		Name:         "exoscale_affinity",
+		Dependencies: []string{"exoscale_compute"},
+		F:            testSweepAffinity,
+	})
+}
+
+func testSweepAffinity(zoneName string) error {
+	config, err := testSweeperConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := GetComputeClient(config)
+	if err != nil {
+		return err
+	}
+
+	ags, err := client.ListWithContext(ctx, &egoscale.AffinityGroup{})
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, item := range ags {
+		ag := item.(*egoscale.AffinityGroup)
+		if !strings.HasPrefix(ag.Name, testPrefix) {
+			continue
+		}
+
+		log.Printf("[INFO] sweeping exoscale_affinity %s (ID = %s)", ag.Name, ag.ID)
+		if err := client.DeleteWithContext(ctx, ag); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to delete affinity group %s: %s", ag.ID, err))
+		}
+	}
+
+	return testSweepErrors(errs)
+}
+
 func TestAccResourceAffinity(t *testing.T) {
 	ag := new(egoscale.AffinityGroup)
 
@@ -63,7 +108,10 @@ func testAccCheckResourceAffinityExists(n string, ag *egoscale.AffinityGroup) re
 			return err
 		}
 
-		client := GetComputeClient(testAccProvider.Meta())
+		client, err := GetComputeClient(testAccProvider.Meta())
+		if err != nil {
+			return err
+		}
 
 		ag.ID = id
 		resp, err := client.Get(ag)
@@ -100,7 +148,10 @@ func testAccCheckResourceAffinityAttributes(expected testAttrs) resource.TestChe
 }
 
 func testAccCheckResourceAffinityDestroy(s *terraform.State) error {
-	client := GetComputeClient(testAccProvider.Meta())
+	client, err := GetComputeClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "exoscale_affinity" {
@@ -4,10 +4,16 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"log"
+	"net"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -24,14 +30,21 @@ func resourceComputeIDString(d resourceIDStringer) string {
 func resourceCompute() *schema.Resource {
 	s := map[string]*schema.Schema{
 		"zone": {
-			Type:     schema.TypeString,
-			Required: true,
-			ForceNew: true,
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "Name of the zone (by default: the provider zone)",
 		},
 		"template": {
-			Type:     schema.TypeString,
-			Required: true,
-			ForceNew: true,
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "Name or ID of the template, changing it replaces the Compute instance unless rebuild_on_template_change is set",
+		},
+		"rebuild_on_template_change": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Reinstall the Compute instance in place with the new template rather than replacing it",
 		},
 		"disk_size": {
 			Type:         schema.TypeInt,
@@ -41,7 +54,6 @@ func resourceCompute() *schema.Resource {
 		"key_pair": {
 			Type:     schema.TypeString,
 			Required: true,
-			ForceNew: true,
 		},
 		"name": {
 			Type:     schema.TypeString,
@@ -115,7 +127,6 @@ func resourceCompute() *schema.Resource {
 		"affinity_group_ids": {
 			Type:          schema.TypeSet,
 			Optional:      true,
-			ForceNew:      true,
 			Computed:      true,
 			Set:           schema.HashString,
 			ConflictsWith: []string{"affinity_groups"},
@@ -126,7 +137,6 @@ func resourceCompute() *schema.Resource {
 		"affinity_groups": {
 			Type:          schema.TypeSet,
 			Optional:      true,
-			ForceNew:      true,
 			Computed:      true,
 			Set:           schema.HashString,
 			ConflictsWith: []string{"affinity_group_ids"},
@@ -154,6 +164,35 @@ func resourceCompute() *schema.Resource {
 				Type: schema.TypeString,
 			},
 		},
+		"network_interface": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "Private networks joined by the Compute instance, at deploy time",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"network_id": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"ip_address": {
+						Type:         schema.TypeString,
+						Optional:     true,
+						Computed:     true,
+						Description:  "Static IP address, on managed private networks",
+						ValidateFunc: ValidateIPv4String,
+					},
+					"mac_address": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+				},
+			},
+		},
+		"default_nic_network_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "ID of the private network of the default NIC (by default: the public network NIC)",
+		},
 		"username": {
 			Type:     schema.TypeString,
 			Computed: true,
@@ -163,9 +202,45 @@ func resourceCompute() *schema.Resource {
 			Computed:  true,
 			Sensitive: true,
 		},
+		"reverse_dns": {
+			Type:             schema.TypeString,
+			Optional:         true,
+			ValidateFunc:     ValidateFQDN,
+			DiffSuppressFunc: suppressReverseDNSDiff,
+			Description:      "Domain name of the PTR record of the Compute instance IP addresses",
+		},
+		"iso": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Name or ID of the ISO attached to the Compute instance",
+		},
+		"password_reset_trigger": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Arbitrary value, changing it resets the password of the Compute instance",
+		},
+		"password_private_key": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Sensitive:   true,
+			Description: "PEM encoded RSA private key of the key pair, used to decrypt the password",
+		},
+		"expunge": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     true,
+			Description: "Expunge the Compute instance on deletion, otherwise it is only destroyed and can be recovered",
+		},
+		"recover_id": {
+			Type:             schema.TypeString,
+			Optional:         true,
+			DiffSuppressFunc: suppressRecoverIDDiff,
+			Description:      "ID of a destroyed Compute instance to recover, rather than deploying a new one",
+		},
 	}
 
 	addTags(s, "tags")
+	addDeletionProtection(s)
 
 	return &schema.Resource{
 		Schema: s,
@@ -176,6 +251,8 @@ func resourceCompute() *schema.Resource {
 		Delete: resourceComputeDelete,
 		Exists: resourceComputeExists,
 
+		CustomizeDiff: resourceComputeCustomizeDiff(s),
+
 		Importer: &schema.ResourceImporter{
 			State: resourceComputeImport,
 		},
@@ -195,7 +272,14 @@ func resourceComputeCreate(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
+
+	if recoverID := d.Get("recover_id").(string); recoverID != "" {
+		return resourceComputeRecover(ctx, d, meta, recoverID)
+	}
 
 	displayName := d.Get("display_name").(string)
 	hostName := regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9\-]+$`)
@@ -205,18 +289,15 @@ func resourceComputeCreate(d *schema.ResourceData, meta interface{}) error {
 
 	// ServiceOffering
 	size := d.Get("size").(string)
-	resp, err := client.RequestWithContext(ctx, &egoscale.ListServiceOfferings{
-		Name: size,
-	})
+	services, err := getServiceOfferingsByName(ctx, client, size)
 	if err != nil {
 		return err
 	}
 
-	services := resp.(*egoscale.ListServiceOfferingsResponse)
-	if len(services.ServiceOffering) != 1 {
+	if len(services) != 1 {
 		return fmt.Errorf("Unable to find the size: %#v", size)
 	}
-	service := services.ServiceOffering[0].ID
+	service := services[0].ID
 
 	// XXX Use Generic Get...
 	zoneName := d.Get("zone").(string)
@@ -226,49 +307,11 @@ func resourceComputeCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 
 	diskSize := int64(d.Get("disk_size").(int))
-	resp, err = client.RequestWithContext(ctx, &egoscale.ListTemplates{
-		TemplateFilter: "featured",
-		ZoneID:         zone.ID,
-	})
+	templateID, username, err := getComputeTemplate(ctx, client, zone.ID, d.Get("template").(string), diskSize)
 	if err != nil {
 		return err
 	}
 
-	var templateID *egoscale.UUID
-	username := ""
-	currentDiskSize := diskSize << 30 // Gib to B
-	image := strings.ToLower(d.Get("template").(string))
-
-	// First try to parse the image value as a UUID, if it fails try as a name
-	if templateID, err = egoscale.ParseUUID(image); err != nil {
-		for _, template := range resp.(*egoscale.ListTemplatesResponse).Template {
-			// Skip non-machine images
-			if strings.ToLower(template.Name) != image {
-				continue
-			}
-
-			if name, ok := template.Details["username"]; username == "" && ok {
-				username = name
-			}
-
-			// Pick the smallest disk size
-			if template.Size <= currentDiskSize {
-				currentDiskSize = template.Size
-				templateID = template.ID
-				continue
-			}
-		}
-	}
-
-	if templateID == nil {
-		return fmt.Errorf("Template not found: %s (%dGB Disk)", d.Get("template").(string), d.Get("disk_size").(int))
-	}
-
-	if username == "" {
-		log.Printf("[INFO] Username not found in the template details, falling back to root.")
-		username = "root"
-	}
-
 	// Affinity Groups
 	var affinityGroups []string
 	if affinitySet, ok := d.Get("affinity_groups").(*schema.Set); ok {
@@ -322,6 +365,44 @@ func resourceComputeCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 	startVM := d.Get("state").(string) != "Stopped"
 
+	// Private networks, the static IP addresses are set before the first boot
+	networkInterfaces, err := getComputeNetworkInterfaces(d.Get("network_interface"))
+	if err != nil {
+		return err
+	}
+
+	var networkIDs []egoscale.UUID
+	staticIPs := false
+	for _, networkInterface := range networkInterfaces {
+		networkIDs = append(networkIDs, *networkInterface.NetworkID)
+		if networkInterface.IP != nil {
+			staticIPs = true
+		}
+	}
+
+	var defaultNetworkID *egoscale.UUID
+	if id := d.Get("default_nic_network_id").(string); id != "" {
+		if err := checkComputeDefaultNic(id, d.Get("network_interface")); err != nil {
+			return err
+		}
+
+		defaultNetworkID, err = egoscale.ParseUUID(id)
+		if err != nil {
+			return err
+		}
+	}
+
+	deployStarted := startVM && !staticIPs && defaultNetworkID == nil
+
+	var isoID *egoscale.UUID
+	if iso := d.Get("iso").(string); iso != "" {
+		found, err := getISO(ctx, client, zone.ID, iso, "executable")
+		if err != nil {
+			return err
+		}
+		isoID = found.ID
+	}
+
 	details := make(map[string]string)
 	details["ip4"] = strconv.FormatBool(d.Get("ip4").(bool))
 	details["ip6"] = strconv.FormatBool(d.Get("ip6").(bool))
@@ -340,11 +421,12 @@ func resourceComputeCreate(d *schema.ResourceData, meta interface{}) error {
 		AffinityGroupNames: affinityGroups,
 		SecurityGroupIDs:   securityGroupIDs,
 		SecurityGroupNames: securityGroups,
+		NetworkIDs:         networkIDs,
 		Details:            details,
-		StartVM:            &startVM,
+		StartVM:            &deployStarted,
 	}
 
-	resp, err = client.RequestWithContext(ctx, req)
+	resp, err := client.RequestWithContext(ctx, req)
 	if err != nil {
 		return err
 	}
@@ -353,7 +435,7 @@ func resourceComputeCreate(d *schema.ResourceData, meta interface{}) error {
 	machine := resp.(*egoscale.VirtualMachine)
 	d.SetId(machine.ID.String())
 
-	cmd, err := createTags(d, "tags", machine.ResourceType())
+	cmd, err := createTags(d, "tags", machine.ResourceType(), meta)
 	if err != nil {
 		return err
 	}
@@ -369,6 +451,65 @@ func resourceComputeCreate(d *schema.ResourceData, meta interface{}) error {
 		}
 	}
 
+	if reverseDNS := d.Get("reverse_dns").(string); reverseDNS != "" {
+		if _, err := client.RequestWithContext(ctx, &egoscale.UpdateReverseDNSForVirtualMachine{
+			ID:         machine.ID,
+			DomainName: reverseDNS,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if isoID != nil {
+		if _, err := client.RequestWithContext(ctx, &egoscale.AttachISO{
+			ID:               isoID,
+			VirtualMachineID: machine.ID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if staticIPs {
+		for _, networkInterface := range networkInterfaces {
+			nic := machine.NicByNetworkID(*networkInterface.NetworkID)
+			if nic == nil {
+				return fmt.Errorf("deployment didn't create a NIC for Network %s", networkInterface.NetworkID)
+			}
+			if networkInterface.IP == nil || networkInterface.IP.Equal(nic.IPAddress) {
+				continue
+			}
+
+			if _, err := client.RequestWithContext(ctx, &egoscale.UpdateVMNicIP{
+				NicID:     nic.ID,
+				IPAddress: networkInterface.IP,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if defaultNetworkID != nil {
+		nic := machine.NicByNetworkID(*defaultNetworkID)
+		if nic == nil {
+			return fmt.Errorf("deployment didn't create a NIC for Network %s", defaultNetworkID)
+		}
+
+		if _, err := client.RequestWithContext(ctx, &egoscale.UpdateDefaultNicForVirtualMachine{
+			NicID:            nic.ID,
+			VirtualMachineID: machine.ID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if startVM && !deployStarted {
+		if _, err := client.RequestWithContext(ctx, &egoscale.StartVirtualMachine{
+			ID: machine.ID,
+		}); err != nil {
+			return err
+		}
+	}
+
 	// Connection info
 	password := ""
 	if machine.PasswordEnabled {
@@ -387,11 +528,208 @@ func resourceComputeCreate(d *schema.ResourceData, meta interface{}) error {
 	return resourceComputeRead(d, meta)
 }
 
+// resourceComputeCustomizeDiff is built along with the schema, so are the
+// deletion protection and the recovery checks
+func resourceComputeCustomizeDiff(s map[string]*schema.Schema) schema.CustomizeDiffFunc {
+	deletionProtection := customizeDiffDeletionProtection(s)
+	replaceKeys := append(forceNewKeys(s), "template")
+
+	return func(d *schema.ResourceDiff, meta interface{}) error {
+		if err := customizeDiffTags("tags")(d, meta); err != nil {
+			return err
+		}
+
+		if err := deletionProtection(d, meta); err != nil {
+			return err
+		}
+
+		if err := checkComputeRecoverReplace(d, replaceKeys); err != nil {
+			return err
+		}
+
+		return resourceComputeCustomizeDiffChanges(d)
+	}
+}
+
+func resourceComputeCustomizeDiffChanges(d *schema.ResourceDiff) error {
+	if d.Id() != "" && d.HasChange("template") && !d.Get("rebuild_on_template_change").(bool) {
+		if err := checkDeletionProtectionReplace(d, "template"); err != nil {
+			return err
+		}
+		if err := d.ForceNew("template"); err != nil {
+			return err
+		}
+	}
+
+	// At creation time, the private network of the default NIC can only be
+	// plugged by a network_interface block. The check waits for the network
+	// IDs to be known.
+	if d.Id() == "" && d.NewValueKnown("default_nic_network_id") {
+		known := true
+		for _, networkInterface := range d.Get("network_interface").([]interface{}) {
+			if networkInterface.(map[string]interface{})["network_id"].(string) == "" {
+				known = false
+			}
+		}
+
+		if known {
+			if err := checkComputeDefaultNic(d.Get("default_nic_network_id").(string), d.Get("network_interface")); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The Anti-Affinity Groups are given either by name or by ID, the other
+	// attribute is known after the update
+	if d.Id() != "" && d.HasChange("affinity_groups") {
+		if err := d.SetNewComputed("affinity_group_ids"); err != nil {
+			return err
+		}
+	} else if d.Id() != "" && d.HasChange("affinity_group_ids") {
+		if err := d.SetNewComputed("affinity_groups"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkComputeRecoverReplace refuses the plans replacing the Compute instance
+// while recover_id is set: its replacement would recover the destroyed Compute
+// instance again, rather than deploying a new one
+func checkComputeRecoverReplace(d *schema.ResourceDiff, keys []string) error {
+	recoverID := d.Get("recover_id").(string)
+	if d.Id() == "" || recoverID == "" {
+		return nil
+	}
+
+	for _, key := range keys {
+		if !d.HasChange(key) {
+			continue
+		}
+		if key == "template" && d.Get("rebuild_on_template_change").(bool) {
+			continue
+		}
+
+		return fmt.Errorf("recover_id is set, changing %q would replace the Compute instance by recovering %s again. Remove recover_id from the configuration", key, recoverID)
+	}
+
+	return nil
+}
+
+// checkComputeDefaultNic checks that the private network of the default NIC is
+// joined by one of the network_interface blocks
+func checkComputeDefaultNic(networkID string, networkInterfaces interface{}) error {
+	if networkID == "" {
+		return nil
+	}
+
+	for _, networkInterface := range networkInterfaces.([]interface{}) {
+		if networkInterface.(map[string]interface{})["network_id"].(string) == networkID {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("the default NIC network %s must be given by a network_interface", networkID)
+}
+
+// getComputeTemplate finds the template, by ID or by name, and the username
+// to log into the Compute instances based on it
+func getComputeTemplate(ctx context.Context, client *computeClient, zoneID *egoscale.UUID, template string, diskSize int64) (*egoscale.UUID, string, error) {
+	templates, err := getTemplates(ctx, client, egoscale.ListTemplates{
+		TemplateFilter: "featured",
+		ZoneID:         zoneID,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	var templateID *egoscale.UUID
+	username := ""
+	currentDiskSize := diskSize << 30 // Gib to B
+	image := strings.ToLower(template)
+
+	// First try to parse the image value as a UUID, if it fails try as a name
+	if templateID, err = egoscale.ParseUUID(image); err != nil {
+		for _, template := range templates {
+			// Skip non-machine images
+			if strings.ToLower(template.Name) != image {
+				continue
+			}
+
+			if name, ok := template.Details["username"]; username == "" && ok {
+				username = name
+			}
+
+			// Pick the smallest disk size
+			if template.Size <= currentDiskSize {
+				currentDiskSize = template.Size
+				templateID = template.ID
+				continue
+			}
+		}
+	}
+
+	if templateID == nil {
+		return nil, "", fmt.Errorf("Template not found: %s (%dGB Disk)", template, diskSize)
+	}
+
+	if username == "" {
+		log.Printf("[INFO] Username not found in the template details, falling back to root.")
+		username = "root"
+	}
+
+	return templateID, username, nil
+}
+
+// getComputeNetworkInterfaces reads the network_interface blocks
+func getComputeNetworkInterfaces(networkInterfaces interface{}) ([]egoscale.IPToNetwork, error) {
+	list := networkInterfaces.([]interface{})
+	ipToNetworkList := make([]egoscale.IPToNetwork, 0, len(list))
+	for _, item := range list {
+		networkInterface := item.(map[string]interface{})
+
+		networkID, err := egoscale.ParseUUID(networkInterface["network_id"].(string))
+		if err != nil {
+			return nil, err
+		}
+
+		if findNetworkInterface(ipToNetworkList, networkID) != nil {
+			return nil, fmt.Errorf("network %s is given by more than one network_interface", networkID)
+		}
+
+		var ip net.IP
+		if ipAddress, ok := networkInterface["ip_address"].(string); ok && ipAddress != "" {
+			ip = net.ParseIP(ipAddress)
+		}
+
+		ipToNetworkList = append(ipToNetworkList, egoscale.IPToNetwork{
+			IP:        ip,
+			NetworkID: networkID,
+		})
+	}
+
+	return ipToNetworkList, nil
+}
+
+func findNetworkInterface(networkInterfaces []egoscale.IPToNetwork, networkID *egoscale.UUID) *egoscale.IPToNetwork {
+	for i := range networkInterfaces {
+		if networkInterfaces[i].NetworkID.Equal(*networkID) {
+			return &networkInterfaces[i]
+		}
+	}
+
+	return nil
+}
+
 func resourceComputeExists(d *schema.ResourceData, meta interface{}) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return false, err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -416,7 +754,10 @@ func resourceComputeRead(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -454,6 +795,21 @@ func resourceComputeRead(d *schema.ResourceData, meta interface{}) error {
 		return err
 	}
 
+	// reverse_dns
+	resp, err = client.RequestWithContext(ctx, &egoscale.QueryReverseDNSForVirtualMachine{
+		ID: id,
+	})
+	if err != nil {
+		return err
+	}
+	reverseDNS := ""
+	if nic := getComputePublicNic(resp.(*egoscale.VirtualMachine)); nic != nil {
+		reverseDNS = getReverseDNS(nic.ReverseDNS)
+	}
+	if err := d.Set("reverse_dns", reverseDNS); err != nil {
+		return err
+	}
+
 	// disk_size
 	volumes, err := client.ListWithContext(ctx, &egoscale.Volume{
 		VirtualMachineID: id,
@@ -483,7 +839,8 @@ func resourceComputeRead(d *schema.ResourceData, meta interface{}) error {
 	}
 
 	password := d.Get("password").(string)
-	if machine.PasswordEnabled && password == "" {
+	privateKey := d.Get("password_private_key").(string)
+	if machine.PasswordEnabled && (password == "" || (privateKey != "" && strings.HasPrefix(password, "base64:"))) {
 		resp, err := client.RequestWithContext(ctx, &egoscale.GetVMPassword{
 			ID: machine.ID,
 		})
@@ -499,15 +856,69 @@ func resourceComputeRead(d *schema.ResourceData, meta interface{}) error {
 			pwd := resp.(*egoscale.Password)
 			// XXX https://cwiki.apache.org/confluence/pages/viewpage.action?pageId=34014652
 			password = fmt.Sprintf("base64:%s", pwd.EncryptedPassword)
+			if privateKey != "" {
+				password, err = decryptPassword(privateKey, pwd.EncryptedPassword)
+				if err != nil {
+					return fmt.Errorf("unable to decrypt the password: %s", err)
+				}
+			}
 			if err := d.Set("password", password); err != nil {
 				return err
 			}
 		}
 	}
 
+	// network_interface, the other private networks NICs belong to exoscale_nic resources
+	networkInterfaces, err := getComputeNetworkInterfaces(d.Get("network_interface"))
+	if err != nil {
+		return err
+	}
+	nics := make([]map[string]interface{}, 0, len(networkInterfaces))
+	for _, networkInterface := range networkInterfaces {
+		nic := machine.NicByNetworkID(*networkInterface.NetworkID)
+		if nic == nil {
+			continue
+		}
+
+		ipAddress := ""
+		if nic.IPAddress != nil {
+			ipAddress = nic.IPAddress.String()
+		}
+		nics = append(nics, map[string]interface{}{
+			"network_id":  nic.NetworkID.String(),
+			"ip_address":  ipAddress,
+			"mac_address": nic.MACAddress.String(),
+		})
+	}
+	if err := d.Set("network_interface", nics); err != nil {
+		return err
+	}
+
+	connectionType, err := getComputeConnectionType(ctx, client, machine)
+	if err != nil {
+		return err
+	}
+
+	if err := resourceComputeApply(d, machine, meta); err != nil {
+		return err
+	}
+
+	// Connection info for the provisioners
+	connInfo := map[string]string{
+		"type": connectionType,
+		"user": d.Get("username").(string),
+		"host": d.Get("ip_address").(string),
+	}
+
+	if d.Get("password").(string) != "" {
+		connInfo["password"] = d.Get("password").(string)
+	}
+
+	d.SetConnInfo(connInfo)
+
 	log.Printf("[DEBUG] %s: read finished successfully", resourceComputeIDString(d))
 
-	return resourceComputeApply(d, machine)
+	return nil
 }
 
 func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
@@ -516,8 +927,22 @@ func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutUpdate))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
+
+	if err := resourceComputeUpdateChanges(ctx, d, meta, client, d); err != nil {
+		return err
+	}
 
+	log.Printf("[DEBUG] %s: update finished successfully", resourceComputeIDString(d))
+
+	return resourceComputeRead(d, meta)
+}
+
+// resourceComputeUpdateChanges applies the changes to the Compute instance
+func resourceComputeUpdateChanges(ctx context.Context, d *schema.ResourceData, meta interface{}, client *computeClient, changes resourceChanges) error {
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
 		return err
@@ -525,8 +950,8 @@ func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
 
 	// Get() gives us the new state
 	initialState := d.Get("state").(string)
-	if d.HasChange("state") {
-		o, _ := d.GetChange("state")
+	if changes.HasChange("state") {
+		o, _ := changes.GetChange("state")
 		initialState = o.(string)
 	}
 
@@ -547,11 +972,11 @@ func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
 		ID: id,
 	}
 
-	if d.HasChange("display_name") {
+	if changes.HasChange("display_name") {
 		req.DisplayName = d.Get("display_name").(string)
 	}
 
-	if d.HasChange("user_data") {
+	if changes.HasChange("user_data") {
 		userData, base64Encoded, err := prepareUserData(d, meta, "user_data")
 		if err != nil {
 			return err
@@ -565,7 +990,7 @@ func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
 		}
 	}
 
-	if d.HasChange("security_groups") {
+	if changes.HasChange("security_groups") {
 		rebootRequired = true
 
 		securityGroupIDs := make([]egoscale.UUID, 0)
@@ -584,7 +1009,7 @@ func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
 		}
 
 		req.SecurityGroupIDs = securityGroupIDs
-	} else if d.HasChange("security_group_ids") {
+	} else if changes.HasChange("security_group_ids") {
 		rebootRequired = true
 
 		securityGroupIDs := make([]egoscale.UUID, 0)
@@ -605,8 +1030,190 @@ func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
 		req.SecurityGroupIDs = securityGroupIDs
 	}
 
-	if d.HasChange("disk_size") {
-		o, n := d.GetChange("disk_size")
+	if changes.HasChange("affinity_groups") {
+		rebootRequired = true
+
+		affinityGroups := make([]string, 0)
+		if affinitySet, ok := d.Get("affinity_groups").(*schema.Set); ok {
+			for _, group := range affinitySet.List() {
+				affinityGroups = append(affinityGroups, group.(string))
+			}
+		}
+
+		commands = append(commands, partialCommand{
+			partials: []string{"affinity_groups", "affinity_group_ids"},
+			request: &egoscale.UpdateVMAffinityGroup{
+				ID:                 id,
+				AffinityGroupNames: affinityGroups,
+			},
+		})
+	} else if changes.HasChange("affinity_group_ids") {
+		rebootRequired = true
+
+		affinityGroupIDs := make([]egoscale.UUID, 0)
+		if affinitySet, ok := d.Get("affinity_group_ids").(*schema.Set); ok {
+			for _, group := range affinitySet.List() {
+				id, err := egoscale.ParseUUID(group.(string))
+				if err != nil {
+					return err
+				}
+				affinityGroupIDs = append(affinityGroupIDs, *id)
+			}
+		}
+
+		commands = append(commands, partialCommand{
+			partials: []string{"affinity_groups", "affinity_group_ids"},
+			request: &egoscale.UpdateVMAffinityGroup{
+				ID:               id,
+				AffinityGroupIDs: affinityGroupIDs,
+			},
+		})
+	}
+
+	if changes.HasChange("reverse_dns") {
+		var request egoscale.Command = &egoscale.DeleteReverseDNSFromVirtualMachine{ID: id}
+		if reverseDNS := d.Get("reverse_dns").(string); reverseDNS != "" {
+			request = &egoscale.UpdateReverseDNSForVirtualMachine{
+				ID:         id,
+				DomainName: reverseDNS,
+			}
+		}
+
+		commands = append(commands, partialCommand{
+			partial: "reverse_dns",
+			request: request,
+		})
+	}
+
+	// Private networks, they don't require the Compute instance to be stopped
+	nicCommands := make([]partialCommand, 0)
+	if changes.HasChange("network_interface") {
+		o, n := changes.GetChange("network_interface")
+		oldNetworkInterfaces, err := getComputeNetworkInterfaces(o)
+		if err != nil {
+			return err
+		}
+		newNetworkInterfaces, err := getComputeNetworkInterfaces(n)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.GetWithContext(ctx, &egoscale.VirtualMachine{ID: id})
+		if err != nil {
+			return err
+		}
+		machine := resp.(*egoscale.VirtualMachine)
+
+		for _, networkInterface := range oldNetworkInterfaces {
+			if findNetworkInterface(newNetworkInterfaces, networkInterface.NetworkID) != nil {
+				continue
+			}
+
+			nic := machine.NicByNetworkID(*networkInterface.NetworkID)
+			if nic == nil {
+				continue
+			}
+
+			nicCommands = append(nicCommands, partialCommand{
+				partial: "network_interface",
+				request: &egoscale.RemoveNicFromVirtualMachine{
+					NicID:            nic.ID,
+					VirtualMachineID: id,
+				},
+			})
+		}
+
+		for _, networkInterface := range newNetworkInterfaces {
+			old := findNetworkInterface(oldNetworkInterfaces, networkInterface.NetworkID)
+			if old == nil {
+				nicCommands = append(nicCommands, partialCommand{
+					partial: "network_interface",
+					request: &egoscale.AddNicToVirtualMachine{
+						NetworkID:        networkInterface.NetworkID,
+						VirtualMachineID: id,
+						IPAddress:        networkInterface.IP,
+					},
+				})
+				continue
+			}
+
+			nic := machine.NicByNetworkID(*networkInterface.NetworkID)
+			if nic == nil || networkInterface.IP == nil || networkInterface.IP.Equal(old.IP) {
+				continue
+			}
+
+			nicCommands = append(nicCommands, partialCommand{
+				partial: "network_interface",
+				request: &egoscale.UpdateVMNicIP{
+					NicID:     nic.ID,
+					IPAddress: networkInterface.IP,
+				},
+			})
+		}
+	}
+
+	// Default NIC, it is looked up once the private networks are plugged
+	updateDefaultNic := changes.HasChange("default_nic_network_id")
+	defaultNicNetworkID := d.Get("default_nic_network_id").(string)
+
+	// ISO, it doesn't require the Compute instance to be stopped
+	isoCommands := make([]partialCommand, 0)
+	if changes.HasChange("iso") {
+		o, n := changes.GetChange("iso")
+
+		if o.(string) != "" {
+			isoCommands = append(isoCommands, partialCommand{
+				partial: "iso",
+				request: &egoscale.DetachISO{VirtualMachineID: id},
+			})
+		}
+
+		if n.(string) != "" {
+			zone, err := getZoneByName(ctx, client, d.Get("zone").(string))
+			if err != nil {
+				return err
+			}
+
+			iso, err := getISO(ctx, client, zone.ID, n.(string), "executable")
+			if err != nil {
+				return err
+			}
+
+			isoCommands = append(isoCommands, partialCommand{
+				partial: "iso",
+				request: &egoscale.AttachISO{
+					ID:               iso.ID,
+					VirtualMachineID: id,
+				},
+			})
+		}
+	}
+
+	// Template, only changed in place when rebuild_on_template_change is set
+	var restore *egoscale.RestoreVirtualMachine
+	username := ""
+	if changes.HasChange("template") {
+		zone, err := getZoneByName(ctx, client, d.Get("zone").(string))
+		if err != nil {
+			return err
+		}
+
+		templateID, templateUsername, err := getComputeTemplate(ctx, client, zone.ID, d.Get("template").(string), int64(d.Get("disk_size").(int)))
+		if err != nil {
+			return err
+		}
+
+		rebootRequired = true
+		username = templateUsername
+		restore = &egoscale.RestoreVirtualMachine{
+			VirtualMachineID: id,
+			TemplateID:       templateID,
+			RootDiskSize:     int64(d.Get("disk_size").(int)),
+		}
+	}
+
+	if changes.HasChange("disk_size") {
+		o, n := changes.GetChange("disk_size")
 		oldSize := o.(int)
 		newSize := n.(int)
 
@@ -615,6 +1222,10 @@ func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
 		}
 
 		rebootRequired = true
+	}
+
+	// The restored root volume is resized afterwards
+	if changes.HasChange("disk_size") && restore == nil {
 
 		volumes, err := client.ListWithContext(ctx, &egoscale.Volume{
 			VirtualMachineID: id,
@@ -636,21 +1247,18 @@ func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
 		})
 	}
 
-	if d.HasChange("size") {
-		o, n := d.GetChange("size")
+	if changes.HasChange("size") {
+		o, n := changes.GetChange("size")
 		oldSize := o.(string)
 		newSize := n.(string)
 		if !strings.EqualFold(oldSize, newSize) {
 			rebootRequired = true
-			resp, err := client.RequestWithContext(ctx, &egoscale.ListServiceOfferings{
-				Name: newSize,
-			})
+			services, err := getServiceOfferingsByName(ctx, client, newSize)
 			if err != nil {
 				return err
 			}
 
-			services := resp.(*egoscale.ListServiceOfferingsResponse)
-			if len(services.ServiceOffering) != 1 {
+			if len(services) != 1 {
 				return fmt.Errorf("size %q not found", newSize)
 			}
 
@@ -658,13 +1266,30 @@ func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
 				partial: "size",
 				request: &egoscale.ScaleVirtualMachine{
 					ID:                id,
-					ServiceOfferingID: services.ServiceOffering[0].ID,
+					ServiceOfferingID: services[0].ID,
 				},
 			})
 		}
 	}
 
-	updates, err := updateTags(d, "tags", "userVM")
+	resetPassword := changes.HasChange("password_reset_trigger")
+	if resetPassword {
+		rebootRequired = true
+	}
+
+	if changes.HasChange("key_pair") {
+		rebootRequired = true
+
+		commands = append(commands, partialCommand{
+			partial: "key_pair",
+			request: &egoscale.ResetSSHKeyForVirtualMachine{
+				ID:      id,
+				KeyPair: d.Get("key_pair").(string),
+			},
+		})
+	}
+
+	updates, err := updateTagsChanges(d, changes, "tags", "userVM", meta)
 	if err != nil {
 		return err
 	}
@@ -675,17 +1300,17 @@ func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
 		})
 	}
 
-	if d.HasChange("ip4") {
+	if changes.HasChange("ip4") {
 		activateIP4 := d.Get("ip4").(bool)
 		if !activateIP4 {
 			return errors.New("the IPv4 address cannot be deactivated")
 		}
 	}
 
-	if d.HasChange("ip6") {
+	if changes.HasChange("ip6") {
 		activateIP6 := d.Get("ip6").(bool)
 		if activateIP6 {
-			resp, err := client.Request(&egoscale.ListNics{VirtualMachineID: id})
+			resp, err := client.RequestWithContext(ctx, &egoscale.ListNics{VirtualMachineID: id})
 			if err != nil {
 				return err
 			}
@@ -704,7 +1329,7 @@ func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
 		}
 	}
 
-	if d.HasChange("state") {
+	if changes.HasChange("state") {
 		switch d.Get("state").(string) {
 		case "Running":
 			startRequired = true
@@ -729,12 +1354,85 @@ func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
 		}
 
 		m := resp.(*egoscale.VirtualMachine)
-		if err := resourceComputeApply(d, m); err != nil {
+		if err := resourceComputeApply(d, m, meta); err != nil {
 			return err
 		}
 		d.SetPartial("state")
 	}
 
+	// Restore, the root volume is reinstalled from the new template
+	if restore != nil {
+		if err := resourceComputeRestore(ctx, d, client, restore, username); err != nil {
+			return err
+		}
+	}
+
+	// Reset the password, when it's given encrypted the read below fetches it
+	if resetPassword {
+		resp, err := client.RequestWithContext(ctx, &egoscale.ResetPasswordForVirtualMachine{
+			ID: id,
+		})
+		if err != nil {
+			return err
+		}
+
+		password := ""
+		if m := resp.(*egoscale.VirtualMachine); m.PasswordEnabled {
+			password = m.Password
+		}
+
+		if err := d.Set("password", password); err != nil {
+			return err
+		}
+		d.SetPartial("password")
+		d.SetPartial("password_reset_trigger")
+	}
+
+	// Plugged before the read below, which only refreshes the known private networks
+	for _, cmd := range nicCommands {
+		if _, err := client.RequestWithContext(ctx, cmd.request); err != nil {
+			return err
+		}
+		d.SetPartial(cmd.partial)
+	}
+
+	// Default NIC, its network may have been plugged right above
+	if updateDefaultNic {
+		resp, err := client.GetWithContext(ctx, &egoscale.VirtualMachine{ID: id})
+		if err != nil {
+			return err
+		}
+		machine := resp.(*egoscale.VirtualMachine)
+
+		nic := getComputePublicNic(machine)
+		if defaultNicNetworkID != "" {
+			defaultNetworkID, err := egoscale.ParseUUID(defaultNicNetworkID)
+			if err != nil {
+				return err
+			}
+			nic = machine.NicByNetworkID(*defaultNetworkID)
+		}
+		if nic == nil {
+			return fmt.Errorf("VM %s has no NIC in the network %q", d.Id(), defaultNicNetworkID)
+		}
+
+		if _, err := client.RequestWithContext(ctx, &egoscale.UpdateDefaultNicForVirtualMachine{
+			NicID:            nic.ID,
+			VirtualMachineID: id,
+		}); err != nil {
+			return err
+		}
+		d.SetPartial("default_nic_network_id")
+	}
+
+	// Attached before the read below, it keeps the ISO given by name or by ID
+	for _, cmd := range isoCommands {
+		if _, err := client.RequestWithContext(ctx, cmd.request); err != nil {
+			return err
+		}
+		d.SetPartial(cmd.partial)
+	}
+
 	// Update, we ignore the result as a full read is require for the user-data/volume
 	_, err = client.RequestWithContext(ctx, req)
 	if err != nil {
@@ -774,18 +1472,172 @@ func resourceComputeUpdate(d *schema.ResourceData, meta interface{}) error {
 
 	d.Partial(false)
 
-	log.Printf("[DEBUG] %s: update finished successfully", resourceComputeIDString(d))
+	return nil
+}
+
+// resourceComputeRecover brings back a destroyed Compute instance, rather than
+// deploying a new one
+func resourceComputeRecover(ctx context.Context, d *schema.ResourceData, meta interface{}, recoverID string) error {
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
+
+	id, err := egoscale.ParseUUID(recoverID)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.RequestWithContext(ctx, &egoscale.RecoverVirtualMachine{ID: id})
+	if err != nil {
+		return err
+	}
+
+	machine := resp.(*egoscale.VirtualMachine)
+	d.SetId(machine.ID.String())
+
+	// The recovered Compute instance is read, on top of the configuration, to
+	// update the attributes which differ like an update would
+	r := resourceCompute()
+	recovered := r.Data(nil)
+	for key := range r.Schema {
+		if value, ok := d.GetOk(key); ok {
+			if err := recovered.Set(key, value); err != nil {
+				return err
+			}
+		}
+	}
+	recovered.SetId(d.Id())
+	if err := resourceComputeRead(recovered, meta); err != nil {
+		return err
+	}
+
+	// The recovered Compute instance is stopped
+	if d.Get("state").(string) == "" {
+		if err := d.Set("state", "Running"); err != nil {
+			return err
+		}
+	}
+
+	if err := resourceComputeUpdateChanges(ctx, d, meta, client, &computeRecoverChanges{
+		d:         d,
+		recovered: recovered,
+		schema:    r.Schema,
+	}); err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] %s: create finished successfully", resourceComputeIDString(d))
 
 	return resourceComputeRead(d, meta)
 }
 
+// computeRecoverChanges compares the configuration with the recovered Compute
+// instance. The template of the recovered Compute instance is kept, so are the
+// attributes computed when they aren't configured.
+type computeRecoverChanges struct {
+	d         *schema.ResourceData
+	recovered *schema.ResourceData
+	schema    map[string]*schema.Schema
+}
+
+func (c *computeRecoverChanges) GetChange(key string) (interface{}, interface{}) {
+	return c.recovered.Get(key), c.d.Get(key)
+}
+
+func (c *computeRecoverChanges) HasChange(key string) bool {
+	if key == "template" || key == "password_reset_trigger" {
+		return false
+	}
+
+	if s, ok := c.schema[key]; ok && s.Optional && s.Computed {
+		if _, ok := c.d.GetOk(key); !ok {
+			return false
+		}
+	}
+
+	o, n := c.GetChange(key)
+	if eq, ok := o.(schema.Equal); ok {
+		return !eq.Equal(n)
+	}
+
+	return !reflect.DeepEqual(o, n)
+}
+
+// suppressRecoverIDDiff ignores the changes of recover_id once the Compute
+// instance exists, it is only used at creation time. Its removal is planned,
+// the Compute instance cannot be replaced until then.
+func suppressRecoverIDDiff(k, old, new string, d *schema.ResourceData) bool {
+	return d.Id() != "" && new != ""
+}
+
+// resourceComputeRestore reinstalls the stopped Compute instance with another
+// template, keeping its disk size
+func resourceComputeRestore(ctx context.Context, d *schema.ResourceData, client *computeClient, restore *egoscale.RestoreVirtualMachine, username string) error {
+	resp, err := client.RequestWithContext(ctx, restore)
+	if err != nil {
+		return err
+	}
+
+	machine := resp.(*egoscale.VirtualMachine)
+
+	// The new root volume may have the size of the template
+	volumes, err := client.ListWithContext(ctx, &egoscale.Volume{
+		VirtualMachineID: restore.VirtualMachineID,
+		Type:             "ROOT",
+	})
+	if err != nil {
+		return err
+	}
+	if len(volumes) != 1 {
+		return fmt.Errorf("ROOT volume not found for the VM %s", d.Id())
+	}
+
+	volume := volumes[0].(*egoscale.Volume)
+	if int64(volume.Size>>30) < restore.RootDiskSize {
+		if _, err := client.RequestWithContext(ctx, &egoscale.ResizeVolume{
+			ID:   volume.ID,
+			Size: restore.RootDiskSize,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Connection info
+	password := ""
+	if machine.PasswordEnabled {
+		password = machine.Password
+	}
+
+	if err := d.Set("username", username); err != nil {
+		return err
+	}
+	if err := d.Set("password", password); err != nil {
+		return err
+	}
+
+	d.SetPartial("template")
+	d.SetPartial("disk_size")
+	d.SetPartial("username")
+	d.SetPartial("password")
+
+	return nil
+}
+
 func resourceComputeDelete(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[DEBUG] %s: beginning delete", resourceComputeIDString(d))
 
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutDelete))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	if err := checkDeletionProtection(d, "exoscale_compute"); err != nil {
+		return err
+	}
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -798,6 +1650,16 @@ func resourceComputeDelete(d *schema.ResourceData, meta interface{}) error {
 		return err
 	}
 
+	// Until it is expunged, the destroyed Compute instance can be recovered
+	if d.Get("expunge").(bool) {
+		if err := client.BooleanRequestWithContext(ctx, &egoscale.ExpungeVirtualMachine{ID: id}); err != nil {
+			// It may have been expunged right away
+			if r, ok := err.(*egoscale.ErrorResponse); !ok || r.ErrorCode != egoscale.ParamError {
+				return err
+			}
+		}
+	}
+
 	log.Printf("[DEBUG] %s: delete finished successfully", resourceComputeIDString(d))
 
 	return nil
@@ -807,7 +1669,10 @@ func resourceComputeImport(d *schema.ResourceData, meta interface{}) ([]*schema.
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return nil, err
+	}
 
 	machine := &egoscale.VirtualMachine{}
 
@@ -836,6 +1701,17 @@ func resourceComputeImport(d *schema.ResourceData, meta interface{}) ([]*schema.
 	secondaryIPs := defaultNic.SecondaryIP
 	nics := vm.NicsByType("Isolated")
 
+	// Options not held by the API take their default value
+	if err := d.Set("rebuild_on_template_change", false); err != nil {
+		return nil, err
+	}
+	if err := d.Set("expunge", true); err != nil {
+		return nil, err
+	}
+	if err := d.Set("deletion_protection", false); err != nil {
+		return nil, err
+	}
+
 	resources := make([]*schema.ResourceData, 0, 1+len(nics)+len(secondaryIPs))
 	resources = append(resources, d)
 
@@ -869,7 +1745,7 @@ func resourceComputeImport(d *schema.ResourceData, meta interface{}) ([]*schema.
 	return resources, nil
 }
 
-func resourceComputeApply(d *schema.ResourceData, machine *egoscale.VirtualMachine) error {
+func resourceComputeApply(d *schema.ResourceData, machine *egoscale.VirtualMachine, meta interface{}) error {
 	if err := d.Set("name", machine.Name); err != nil {
 		return err
 	}
@@ -892,14 +1768,35 @@ func resourceComputeApply(d *schema.ResourceData, machine *egoscale.VirtualMachi
 		return err
 	}
 
+	// The ISO is given either by name or by ID
+	iso := ""
+	if machine.IsoID != nil {
+		iso = machine.IsoName
+		if strings.EqualFold(d.Get("iso").(string), machine.IsoID.String()) {
+			iso = machine.IsoID.String()
+		}
+	}
+	if err := d.Set("iso", iso); err != nil {
+		return err
+	}
+
 	d.Set("ip4", false)      // nolint: errcheck
 	d.Set("ip6", false)      // nolint: errcheck
 	d.Set("ip_address", "")  // nolint: errcheck
 	d.Set("gateway", "")     // nolint: errcheck
 	d.Set("ip6_address", "") // nolint: errcheck
 	d.Set("ip6_cidr", "")    // nolint: errcheck
+	if nic := getComputePublicNic(machine); nic != nil {
+		d.Set("ip4", true)                  // nolint: errcheck
+		d.Set("ip6", nic.IP6Address != nil) // nolint: errcheck
+	}
+
+	// The connection info follows the default NIC, which may be a private one
+	defaultNicNetworkID := ""
 	if nic := machine.DefaultNic(); nic != nil {
-		d.Set("ip4", true) // nolint: errcheck
+		if nic.Type != "Shared" {
+			defaultNicNetworkID = nic.NetworkID.String()
+		}
 		if nic.IPAddress != nil {
 			if err := d.Set("ip_address", nic.IPAddress.String()); err != nil {
 				return err
@@ -911,7 +1808,6 @@ func resourceComputeApply(d *schema.ResourceData, machine *egoscale.VirtualMachi
 			}
 		}
 		if nic.IP6Address != nil {
-			d.Set("ip6", true) // nolint: errcheck
 			if err := d.Set("ip6_address", nic.IP6Address.String()); err != nil {
 				return err
 			}
@@ -923,6 +1819,10 @@ func resourceComputeApply(d *schema.ResourceData, machine *egoscale.VirtualMachi
 		}
 	}
 
+	if err := d.Set("default_nic_network_id", defaultNicNetworkID); err != nil {
+		return err
+	}
+
 	// affinity groups
 	affinityGroups := make([]string, len(machine.AffinityGroup))
 	affinityGroupIDs := make([]string, len(machine.AffinityGroup))
@@ -952,28 +1852,52 @@ func resourceComputeApply(d *schema.ResourceData, machine *egoscale.VirtualMachi
 	}
 
 	// tags
-	tags := make(map[string]interface{})
-	for _, tag := range machine.Tags {
-		tags[tag.Key] = tag.Value
-	}
-	if err := d.Set("tags", tags); err != nil {
+	if err := setTags(d, "tags", machine.Tags, meta); err != nil {
 		return err
 	}
 
-	// Connection info for the provisioners
-	connInfo := map[string]string{
-		"type": "ssh",
-		"user": d.Get("username").(string),
-		"host": d.Get("ip_address").(string),
+	return nil
+}
+
+// getComputePublicNic returns the NIC of the public network, it is the
+// default NIC unless default_nic_network_id is set
+func getComputePublicNic(machine *egoscale.VirtualMachine) *egoscale.Nic {
+	for i, nic := range machine.Nic {
+		if nic.Type == "Shared" {
+			return &machine.Nic[i]
+		}
 	}
 
-	if d.Get("password").(string) != "" {
-		connInfo["password"] = d.Get("password").(string)
+	return nil
+}
+
+// getComputeConnectionType returns the provisioners connection type from the
+// OS type of the template: WinRM for Windows, SSH otherwise
+func getComputeConnectionType(ctx context.Context, client *computeClient, machine *egoscale.VirtualMachine) (string, error) {
+	if machine.TemplateID == nil {
+		return "ssh", nil
 	}
 
-	d.SetConnInfo(connInfo)
+	templates, err := getTemplates(ctx, client, egoscale.ListTemplates{
+		TemplateFilter: "executable",
+		ID:             machine.TemplateID,
+		ZoneID:         machine.ZoneID,
+	})
+	if err != nil {
+		// The template may have been deleted since the deployment
+		if r, ok := err.(*egoscale.ErrorResponse); ok && r.ErrorCode == egoscale.ParamError {
+			return "ssh", nil
+		}
+		return "", err
+	}
 
-	return nil
+	for _, template := range templates {
+		if strings.Contains(strings.ToLower(template.OsTypeName), "windows") {
+			return "winrm", nil
+		}
+	}
+
+	return "ssh", nil
 }
 
 func getSSHUsername(template string) string {
@@ -1006,7 +1930,50 @@ func getSSHUsername(template string) string {
 	return "root"
 }
 
-func getSecurityGroup(ctx context.Context, client *egoscale.Client, name string) (*egoscale.SecurityGroup, error) {
+// decryptPassword decrypts the base64 encoded password using the PEM encoded
+// RSA private key of the key pair
+func decryptPassword(privateKey, encryptedPassword string) (string, error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return "", errors.New("password_private_key is not a PEM encoded key")
+	}
+
+	var key *rsa.PrivateKey
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		k, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return "", err
+		}
+		key = k
+	case "PRIVATE KEY":
+		k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return "", err
+		}
+		rsaKey, ok := k.(*rsa.PrivateKey)
+		if !ok {
+			return "", errors.New("password_private_key is not an RSA key")
+		}
+		key = rsaKey
+	default:
+		return "", fmt.Errorf("password_private_key has an unsupported type %q", block.Type)
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(encryptedPassword)
+	if err != nil {
+		return "", err
+	}
+
+	password, err := rsa.DecryptPKCS1v15(rand.Reader, key, encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	return string(password), nil
+}
+
+func getSecurityGroup(ctx context.Context, client *computeClient, name string) (*egoscale.SecurityGroup, error) {
 	sg := &egoscale.SecurityGroup{Name: name}
 
 	resp, err := client.GetWithContext(ctx, sg)
@@ -1034,7 +2001,7 @@ func prepareUserData(d *schema.ResourceData, meta interface{}, key string) (stri
 
 	byteUserData := []byte(userData)
 
-	if meta.(BaseConfig).gzipUserData {
+	if meta.(*BaseConfig).gzipUserData {
 		b := new(bytes.Buffer)
 		gz := gzip.NewWriter(b)
 
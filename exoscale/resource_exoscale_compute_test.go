@@ -1,15 +1,70 @@
 package exoscale
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/exoscale/egoscale"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
+	"golang.org/x/crypto/ssh"
 )
 
+func init() {
+	resource.AddTestSweepers("exoscale_compute", &resource.Sweeper{
+		Name: "exoscale_compute",
+		F:    testSweepCompute,
+	})
+}
+
+func testSweepCompute(zoneName string) error {
+	config, err := testSweeperConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := GetComputeClient(config)
+	if err != nil {
+		return err
+	}
+
+	zone, err := getZoneByName(ctx, client, zoneName)
+	if err != nil {
+		return err
+	}
+
+	vms, err := client.ListWithContext(ctx, &egoscale.VirtualMachine{ZoneID: zone.ID})
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, item := range vms {
+		vm := item.(*egoscale.VirtualMachine)
+		if !strings.HasPrefix(vm.Name, testPrefix) {
+			continue
+		}
+
+		log.Printf("[INFO] sweeping exoscale_compute %s (ID = %s)", vm.Name, vm.ID)
+		if err := client.DeleteWithContext(ctx, vm); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to destroy compute %s: %s", vm.ID, err))
+		}
+	}
+
+	return testSweepErrors(errs)
+}
+
 func TestAccResourceCompute(t *testing.T) {
 	sg := new(egoscale.SecurityGroup)
 	vm := new(egoscale.VirtualMachine)
@@ -35,47 +90,779 @@ func TestAccResourceCompute(t *testing.T) {
 				),
 			},
 			{
-				Config: testAccResourceComputeConfigUpdate,
+				Config: testAccResourceComputeConfigUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceSecurityGroupExists("exoscale_security_group.sg", sg),
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					testAccCheckResourceCompute(vm),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"template":          ValidateString(defaultExoscaleTemplate),
+						"display_name":      ValidateString("terraform-test-compute2"),
+						"size":              ValidateString("Small"),
+						"disk_size":         ValidateString("18"),
+						"key_pair":          ValidateString("terraform-test-keypair"),
+						"security_groups.#": ValidateString("2"),
+						"ip6":               ValidateString("true"),
+						"user_data":         ValidateString("#cloud-config\npackage_upgrade: true\n"),
+					}),
+				),
+			},
+			{
+				ResourceName:            "exoscale_compute.vm",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"password", "user_data_base64"},
+				ImportStateCheck: func(s []*terraform.InstanceState) error {
+					return checkResourceAttributes(
+						testAttrs{
+							"template":          ValidateString(defaultExoscaleTemplate),
+							"display_name":      ValidateString("terraform-test-compute2"),
+							"size":              ValidateString("Small"),
+							"disk_size":         ValidateString("18"),
+							"key_pair":          ValidateString("terraform-test-keypair"),
+							"security_groups.#": ValidateString("2"),
+							"ip6":               ValidateString("true"),
+							"user_data":         ValidateString("#cloud-config\npackage_upgrade: true\n"),
+						},
+						s[0].Attributes)
+				},
+			},
+		},
+	})
+}
+
+func TestAccResourceComputeRebuild(t *testing.T) {
+	vm := new(egoscale.VirtualMachine)
+	rebuilt := new(egoscale.VirtualMachine)
+	replaced := new(egoscale.VirtualMachine)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceComputeDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigRebuild, defaultExoscaleTemplate, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"template":  ValidateString(defaultExoscaleTemplate),
+						"disk_size": ValidateString("12"),
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigRebuild, testAccTemplate2, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", rebuilt),
+					func(s *terraform.State) error {
+						if !rebuilt.ID.Equal(*vm.ID) {
+							return errors.New("expected the Compute instance to be rebuilt in place")
+						}
+						return nil
+					},
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"template":  ValidateString(testAccTemplate2),
+						"disk_size": ValidateString("12"),
+						"state":     ValidateString("Running"),
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigRebuild, defaultExoscaleTemplate, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", replaced),
+					func(s *terraform.State) error {
+						if replaced.ID.Equal(*vm.ID) {
+							return errors.New("expected the Compute instance to be replaced")
+						}
+						return nil
+					},
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"template": ValidateString(defaultExoscaleTemplate),
+					}),
+				),
+			},
+		},
+	})
+}
+
+func TestAccResourceComputeKeyPair(t *testing.T) {
+	vm := new(egoscale.VirtualMachine)
+	updated := new(egoscale.VirtualMachine)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceComputeDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigKeyPair, "key"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"key_pair": ValidateString("terraform-test-keypair"),
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigKeyPair, "key2"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", updated),
+					func(s *terraform.State) error {
+						if !updated.ID.Equal(*vm.ID) {
+							return errors.New("expected the Compute instance to be updated in place")
+						}
+						if updated.KeyPair != "terraform-test-keypair2" {
+							return fmt.Errorf("expected the SSH key pair to be reset, got %q", updated.KeyPair)
+						}
+						return nil
+					},
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"key_pair": ValidateString("terraform-test-keypair2"),
+						"state":    ValidateString("Running"),
+					}),
+				),
+			},
+		},
+	})
+}
+
+// testAccValidateDecryptedPassword checks that the password is set and was
+// decrypted, whatever its format
+func testAccValidateDecryptedPassword(i interface{}, k string) (s []string, es []error) {
+	password := i.(string)
+	if password == "" || strings.HasPrefix(password, "base64:") {
+		es = append(es, fmt.Errorf("%s: expected a decrypted password, got %q", k, password))
+	}
+
+	return
+}
+
+func TestAccResourceComputePassword(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sshPublicKey, err := ssh.NewPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublicKey)))
+	privateKey := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(private),
+	}))
+	decrypt := fmt.Sprintf("password_private_key = <<EOF\n%sEOF", privateKey)
+
+	var password string
+	vm := new(egoscale.VirtualMachine)
+	reset := new(egoscale.VirtualMachine)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceComputeDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigPassword, publicKey, ""),
+				Check: testAccCheckResourceComputeAttributes(testAttrs{
+					"password": ValidateRegexp("^base64:"),
+				}),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigPassword, publicKey, decrypt),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"password": testAccValidateDecryptedPassword,
+					}),
+					func(s *terraform.State) error {
+						password = s.RootModule().Resources["exoscale_compute.vm"].Primary.Attributes["password"]
+						return nil
+					},
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigPassword, publicKey, decrypt+"\n  password_reset_trigger = \"1\""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", reset),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"password":               testAccValidateDecryptedPassword,
+						"password_reset_trigger": ValidateString("1"),
+						"state":                  ValidateString("Running"),
+					}),
+					func(s *terraform.State) error {
+						if !reset.ID.Equal(*vm.ID) {
+							return errors.New("expected the Compute instance to be updated in place")
+						}
+						if s.RootModule().Resources["exoscale_compute.vm"].Primary.Attributes["password"] == password {
+							return errors.New("expected the password to be reset")
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestResourceComputeConnInfo(t *testing.T) {
+	api, meta := newMockComputeConfig(1)
+	defer api.Close()
+
+	for _, tc := range []struct {
+		template       string
+		connectionType string
+		user           string
+	}{
+		{template: defaultExoscaleTemplate, connectionType: "ssh", user: "ubuntu"},
+		{template: testAccWindowsTemplate, connectionType: "winrm", user: "Administrator"},
+	} {
+		d := resourceCompute().TestResourceData()
+		for k, v := range map[string]interface{}{
+			"template":     tc.template,
+			"zone":         defaultExoscaleZone,
+			"display_name": "terraform-test-compute",
+			"size":         "Medium",
+			"disk_size":    50,
+			"ip4":          true,
+		} {
+			if err := d.Set(k, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := resourceComputeCreate(d, meta); err != nil {
+			t.Fatalf("%s: %s", tc.template, err)
+		}
+
+		connInfo := d.State().Ephemeral.ConnInfo
+		if connInfo["type"] != tc.connectionType {
+			t.Errorf("%s: expected the connection type %q, got %q", tc.template, tc.connectionType, connInfo["type"])
+		}
+		if connInfo["user"] != tc.user {
+			t.Errorf("%s: expected the connection user %q, got %q", tc.template, tc.user, connInfo["user"])
+		}
+		if connInfo["host"] == "" {
+			t.Errorf("%s: expected the connection host to be set", tc.template)
+		}
+	}
+}
+
+func TestAccResourceComputeAffinityGroups(t *testing.T) {
+	vm := new(egoscale.VirtualMachine)
+	updated := new(egoscale.VirtualMachine)
+
+	testAccCheckResourceComputeInPlace := func(s *terraform.State) error {
+		if !updated.ID.Equal(*vm.ID) {
+			return errors.New("expected the Compute instance to be updated in place")
+		}
+		return nil
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceComputeDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigAffinityGroups, `affinity_groups = ["${exoscale_affinity.ag1.name}"]`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"affinity_groups.#":    ValidateString("1"),
+						"affinity_group_ids.#": ValidateString("1"),
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigAffinityGroups, `affinity_groups = ["${exoscale_affinity.ag1.name}", "${exoscale_affinity.ag2.name}"]`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", updated),
+					testAccCheckResourceComputeInPlace,
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"affinity_groups.#":    ValidateString("2"),
+						"affinity_group_ids.#": ValidateString("2"),
+						"state":                ValidateString("Running"),
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigAffinityGroups, `affinity_group_ids = ["${exoscale_affinity.ag2.id}"]
+  state = "Stopped"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", updated),
+					testAccCheckResourceComputeInPlace,
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"affinity_groups.#":    ValidateString("1"),
+						"affinity_group_ids.#": ValidateString("1"),
+						"state":                ValidateString("Stopped"),
+					}),
+					func(s *terraform.State) error {
+						if len(updated.AffinityGroup) != 1 || updated.AffinityGroup[0].Name != "terraform-test-affinity2" {
+							return fmt.Errorf("expected the Compute instance to be in the second Anti-Affinity Group, got %v", updated.AffinityGroup)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccResourceComputeISO(t *testing.T) {
+	vm := new(egoscale.VirtualMachine)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceComputeDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigISO, fmt.Sprintf("iso = %q", testAccISO)),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"iso": ValidateString(testAccISO),
+					}),
+					func(s *terraform.State) error {
+						if vm.IsoName != testAccISO {
+							return fmt.Errorf("expected the ISO %q to be attached, got %q", testAccISO, vm.IsoName)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigISO, `iso = "${data.exoscale_iso.netinst.id}"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					resource.TestCheckResourceAttrPair("exoscale_compute.vm", "iso", "data.exoscale_iso.netinst", "id"),
+					func(s *terraform.State) error {
+						if vm.IsoName != testAccISO2 {
+							return fmt.Errorf("expected the ISO %q to be attached, got %q", testAccISO2, vm.IsoName)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigISO, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"iso":   ValidateString(""),
+						"state": ValidateString("Running"),
+					}),
+					func(s *terraform.State) error {
+						if vm.IsoID != nil {
+							return fmt.Errorf("expected the ISO to be detached, got %q", vm.IsoName)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccResourceComputeReverseDNS(t *testing.T) {
+	vm := new(egoscale.VirtualMachine)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceComputeDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigReverseDNS, `reverse_dns = "terraform-test.example.net"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"reverse_dns": ValidateString("terraform-test.example.net"),
+					}),
+					testAccCheckResourceComputeReverseDNS(vm, "terraform-test.example.net."),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigReverseDNS, `reverse_dns = "mail.example.net."`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"reverse_dns": ValidateString("mail.example.net"),
+					}),
+					testAccCheckResourceComputeReverseDNS(vm, "mail.example.net."),
+				),
+			},
+			{
+				// The PTR record removed outside of Terraform is created again
+				PreConfig: func() {
+					client, err := GetComputeClient(testAccProvider.Meta())
+					if err != nil {
+						t.Fatal(err)
+					}
+					if err := client.BooleanRequest(&egoscale.DeleteReverseDNSFromVirtualMachine{ID: vm.ID}); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: fmt.Sprintf(testAccResourceComputeConfigReverseDNS, `reverse_dns = "mail.example.net."`),
+				Check:  testAccCheckResourceComputeReverseDNS(vm, "mail.example.net."),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigReverseDNS, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"reverse_dns": ValidateString(""),
+					}),
+					testAccCheckResourceComputeReverseDNS(vm, ""),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckResourceComputeReverseDNS(vm *egoscale.VirtualMachine, expected string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client, err := GetComputeClient(testAccProvider.Meta())
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Request(&egoscale.QueryReverseDNSForVirtualMachine{ID: vm.ID})
+		if err != nil {
+			return err
+		}
+
+		domainName := ""
+		if nic := resp.(*egoscale.VirtualMachine).DefaultNic(); nic != nil && len(nic.ReverseDNS) > 0 {
+			domainName = nic.ReverseDNS[0].DomainName
+		}
+		if domainName != expected {
+			return fmt.Errorf("expected the PTR record %q, got %q", expected, domainName)
+		}
+
+		return nil
+	}
+}
+
+func TestAccResourceComputeRecover(t *testing.T) {
+	vm := new(egoscale.VirtualMachine)
+	recovered := new(egoscale.VirtualMachine)
+
+	// The Compute instance is only destroyed
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceComputeDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigRecover, "expunge = false"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"expunge": ValidateString("false"),
+					}),
+				),
+			},
+		},
+	})
+
+	if vm.ID == nil {
+		return
+	}
+
+	// then it is recovered with the configured attributes, and expunged
+	mutable := `
+  reverse_dns = "terraform-test.example.net"
+
+  tags = {
+    test = "recovered"
+  }`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceComputeDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigRecover, fmt.Sprintf("recover_id = %q\n%s", vm.ID, mutable)),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", recovered),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"display_name": ValidateString("terraform-test-compute"),
+						"state":        ValidateString("Running"),
+						"username":     ValidateString("ubuntu"),
+						"tags.test":    ValidateString("recovered"),
+					}),
+					testAccCheckResourceComputeReverseDNS(recovered, "terraform-test.example.net."),
+					func(s *terraform.State) error {
+						if !recovered.ID.Equal(*vm.ID) {
+							return fmt.Errorf("expected the Compute instance %s to be recovered, got %s", vm.ID, recovered.ID)
+						}
+						if len(recovered.Tags) != 1 || recovered.Tags[0].Value != "recovered" {
+							return fmt.Errorf("expected the recovered Compute instance to be tagged, got %v", recovered.Tags)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				// The replacement would recover the destroyed Compute instance again
+				Config:      fmt.Sprintf(testAccResourceComputeConfigRecover, fmt.Sprintf("recover_id = %q\nkeyboard = \"fr-ch\"\n%s", vm.ID, mutable)),
+				ExpectError: regexp.MustCompile(`recover_id is set, changing "keyboard" would replace the Compute instance`),
+			},
+			{
+				// Removing recover_id doesn't replace the recovered Compute instance
+				Config: fmt.Sprintf(testAccResourceComputeConfigRecover, mutable),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", recovered),
+					resource.TestCheckResourceAttr("exoscale_compute.vm", "recover_id", ""),
+					func(s *terraform.State) error {
+						if !recovered.ID.Equal(*vm.ID) {
+							return fmt.Errorf("expected the Compute instance %s to be kept, got %s", vm.ID, recovered.ID)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+
+	client, err := GetComputeClient(testAccProvider.Meta())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Request(&egoscale.RecoverVirtualMachine{ID: vm.ID}); err == nil {
+		t.Errorf("expected the Compute instance %s to be expunged", vm.ID)
+	}
+}
+
+func TestAccResourceComputeDeletionProtection(t *testing.T) {
+	vm := new(egoscale.VirtualMachine)
+	updated := new(egoscale.VirtualMachine)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceComputeDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigDeletionProtection, defaultExoscaleTemplate, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"deletion_protection": ValidateString("true"),
+					}),
+				),
+			},
+			{
+				// The protection must be disabled by a separate apply
+				Config:      fmt.Sprintf(testAccResourceComputeConfigDeletionProtection, testAccTemplate2, false),
+				ExpectError: regexp.MustCompile(`deletion protection is enabled, changing "template" would replace the resource`),
+			},
+			{
+				Config:      fmt.Sprintf(testAccResourceComputeConfigDeletionProtection, defaultExoscaleTemplate, true),
+				Destroy:     true,
+				ExpectError: regexp.MustCompile("deletion protection is enabled"),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigDeletionProtection, defaultExoscaleTemplate, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", updated),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"deletion_protection": ValidateString("false"),
+					}),
+					func(s *terraform.State) error {
+						if !updated.ID.Equal(*vm.ID) {
+							return errors.New("expected the Compute instance to be updated in place")
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccResourceComputeNetworkInterfaces(t *testing.T) {
+	vm := new(egoscale.VirtualMachine)
+	updated := new(egoscale.VirtualMachine)
+
+	testAccCheckResourceComputeInPlace := func(s *terraform.State) error {
+		if !updated.ID.Equal(*vm.ID) {
+			return errors.New("expected the Compute instance to be updated in place")
+		}
+		return nil
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceComputeDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigNetworkInterfaces, `
+  network_interface {
+    network_id = "${exoscale_network.managed.id}"
+    ip_address = "10.0.0.1"
+  }
+
+  network_interface {
+    network_id = "${exoscale_network.unmanaged.id}"
+  }`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					resource.TestCheckResourceAttr("exoscale_compute.vm", "network_interface.#", "2"),
+					resource.TestCheckResourceAttrPair("exoscale_compute.vm", "network_interface.0.network_id", "exoscale_network.managed", "id"),
+					resource.TestCheckResourceAttr("exoscale_compute.vm", "network_interface.0.ip_address", "10.0.0.1"),
+					resource.TestCheckResourceAttrPair("exoscale_compute.vm", "network_interface.1.network_id", "exoscale_network.unmanaged", "id"),
+					resource.TestCheckResourceAttrSet("exoscale_compute.vm", "network_interface.1.mac_address"),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"state": ValidateString("Running"),
+					}),
+					testAccCheckResourceComputeNetworkInterfaces(vm, map[string]string{
+						"terraform-test-managed":   "10.0.0.1",
+						"terraform-test-unmanaged": "",
+						"terraform-test-nic":       "",
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigNetworkInterfaces, `
+  network_interface {
+    network_id = "${exoscale_network.managed.id}"
+    ip_address = "10.0.0.3"
+  }`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", updated),
+					testAccCheckResourceComputeInPlace,
+					resource.TestCheckResourceAttr("exoscale_compute.vm", "network_interface.#", "1"),
+					resource.TestCheckResourceAttr("exoscale_compute.vm", "network_interface.0.ip_address", "10.0.0.3"),
+					testAccCheckResourceComputeNetworkInterfaces(updated, map[string]string{
+						"terraform-test-managed": "10.0.0.3",
+						"terraform-test-nic":     "",
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigNetworkInterfaces, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", updated),
+					testAccCheckResourceComputeInPlace,
+					resource.TestCheckResourceAttr("exoscale_compute.vm", "network_interface.#", "0"),
+					testAccCheckResourceComputeNetworkInterfaces(updated, map[string]string{
+						"terraform-test-nic": "",
+					}),
+				),
+			},
+		},
+	})
+}
+
+func TestAccResourceComputeDefaultNic(t *testing.T) {
+	vm := new(egoscale.VirtualMachine)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceComputeDestroy,
+		Steps: []resource.TestStep{
+			{
+				// The check happens at plan time, before any deployment
+				Config: fmt.Sprintf(testAccResourceComputeConfigNetworkInterfaces, `
+  default_nic_network_id = "00000000-0000-0000-0000-000000000000"
+
+  network_interface {
+    network_id = "11111111-1111-1111-1111-111111111111"
+  }`),
+				ExpectError: regexp.MustCompile("the default NIC network 00000000-0000-0000-0000-000000000000 must be given by a network_interface"),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigNetworkInterfaces, `
+  default_nic_network_id = "${exoscale_network.managed.id}"
+
+  network_interface {
+    network_id = "${exoscale_network.managed.id}"
+    ip_address = "10.0.0.1"
+  }`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					resource.TestCheckResourceAttrPair("exoscale_compute.vm", "default_nic_network_id", "exoscale_network.managed", "id"),
+					testAccCheckResourceComputeAttributes(testAttrs{
+						"ip_address": ValidateString("10.0.0.1"),
+						"ip4":        ValidateString("true"),
+						"state":      ValidateString("Running"),
+					}),
+					testAccCheckResourceComputeDefaultNic(vm, "terraform-test-managed"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceComputeConfigNetworkInterfaces, `
+  network_interface {
+    network_id = "${exoscale_network.managed.id}"
+    ip_address = "10.0.0.1"
+  }`),
 				Check: resource.ComposeTestCheckFunc(
-					testAccCheckResourceSecurityGroupExists("exoscale_security_group.sg", sg),
 					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
-					testAccCheckResourceCompute(vm),
 					testAccCheckResourceComputeAttributes(testAttrs{
-						"template":          ValidateString(defaultExoscaleTemplate),
-						"display_name":      ValidateString("terraform-test-compute2"),
-						"size":              ValidateString("Small"),
-						"disk_size":         ValidateString("18"),
-						"key_pair":          ValidateString("terraform-test-keypair"),
-						"security_groups.#": ValidateString("2"),
-						"ip6":               ValidateString("true"),
-						"user_data":         ValidateString("#cloud-config\npackage_upgrade: true\n"),
+						"default_nic_network_id": ValidateString(""),
+						"ip_address":             ValidateIPv4String,
 					}),
+					testAccCheckResourceComputeDefaultNic(vm, "defaultGuestNetwork"),
+					func(s *terraform.State) error {
+						ipAddress := s.RootModule().Resources["exoscale_compute.vm"].Primary.Attributes["ip_address"]
+						if !vm.DefaultNic().IPAddress.Equal(net.ParseIP(ipAddress)) {
+							return fmt.Errorf("expected the ip_address %s to be the default NIC one, got %s", vm.DefaultNic().IPAddress, ipAddress)
+						}
+						return nil
+					},
 				),
 			},
-			{
-				ResourceName:            "exoscale_compute.vm",
-				ImportState:             true,
-				ImportStateVerify:       true,
-				ImportStateVerifyIgnore: []string{"password", "user_data_base64"},
-				ImportStateCheck: func(s []*terraform.InstanceState) error {
-					return checkResourceAttributes(
-						testAttrs{
-							"template":          ValidateString(defaultExoscaleTemplate),
-							"display_name":      ValidateString("terraform-test-compute2"),
-							"size":              ValidateString("Small"),
-							"disk_size":         ValidateString("18"),
-							"key_pair":          ValidateString("terraform-test-keypair"),
-							"security_groups.#": ValidateString("2"),
-							"ip6":               ValidateString("true"),
-							"user_data":         ValidateString("#cloud-config\npackage_upgrade: true\n"),
-						},
-						s[0].Attributes)
-				},
-			},
 		},
 	})
 }
 
+func testAccCheckResourceComputeDefaultNic(vm *egoscale.VirtualMachine, networkName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		nic := vm.DefaultNic()
+		if nic == nil {
+			return errors.New("the Compute instance has no default NIC")
+		}
+
+		if nic.NetworkName != networkName {
+			return fmt.Errorf("expected the default NIC in the network %q, got %q", networkName, nic.NetworkName)
+		}
+
+		return nil
+	}
+}
+
+// testAccCheckResourceComputeNetworkInterfaces checks the private networks
+// NICs of the Compute instance, by network name and IP address
+func testAccCheckResourceComputeNetworkInterfaces(vm *egoscale.VirtualMachine, expected map[string]string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		nics := vm.NicsByType("Isolated")
+		if len(nics) != len(expected) {
+			return fmt.Errorf("expected %d private networks NICs, got %d", len(expected), len(nics))
+		}
+
+		for _, nic := range nics {
+			ipAddress, ok := expected[nic.NetworkName]
+			if !ok {
+				return fmt.Errorf("unexpected NIC in the network %q", nic.NetworkName)
+			}
+
+			if ipAddress != "" && !nic.IPAddress.Equal(net.ParseIP(ipAddress)) {
+				return fmt.Errorf("expected the NIC IP address %s in the network %q, got %s", ipAddress, nic.NetworkName, nic.IPAddress)
+			}
+		}
+
+		return nil
+	}
+}
+
 func testAccCheckResourceComputeExists(n string, vm *egoscale.VirtualMachine) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -92,7 +879,10 @@ func testAccCheckResourceComputeExists(n string, vm *egoscale.VirtualMachine) re
 			return err
 		}
 
-		client := GetComputeClient(testAccProvider.Meta())
+		client, err := GetComputeClient(testAccProvider.Meta())
+		if err != nil {
+			return err
+		}
 
 		resp, err := client.Get(&egoscale.VirtualMachine{
 			ID: id,
@@ -130,7 +920,10 @@ func testAccCheckResourceComputeAttributes(expected testAttrs) resource.TestChec
 }
 
 func testAccCheckResourceComputeDestroy(s *terraform.State) error {
-	client := GetComputeClient(testAccProvider.Meta())
+	client, err := GetComputeClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "exoscale_compute" {
@@ -219,3 +1012,258 @@ EOF
 	defaultExoscaleTemplate,
 	defaultExoscaleZone,
 )
+
+var testAccResourceComputeConfigRebuild = fmt.Sprintf(`
+resource "exoscale_ssh_keypair" "key" {
+  name = "terraform-test-keypair"
+}
+
+resource "exoscale_compute" "vm" {
+  template = "%%s"
+  zone = %q
+  display_name = "terraform-test-compute"
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.key.name}"
+
+  rebuild_on_template_change = %%t
+}
+`,
+	defaultExoscaleZone,
+)
+
+var testAccResourceComputeConfigKeyPair = fmt.Sprintf(`
+resource "exoscale_ssh_keypair" "key" {
+  name = "terraform-test-keypair"
+}
+
+resource "exoscale_ssh_keypair" "key2" {
+  name = "terraform-test-keypair2"
+}
+
+resource "exoscale_compute" "vm" {
+  template = %q
+  zone = %q
+  display_name = "terraform-test-compute"
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.%%s.name}"
+
+  timeouts {
+    delete = "30m"
+  }
+}
+`,
+	defaultExoscaleTemplate,
+	defaultExoscaleZone,
+)
+
+var testAccResourceComputeConfigPassword = fmt.Sprintf(`
+resource "exoscale_ssh_keypair" "key" {
+  name = "terraform-test-keypair"
+  public_key = "%%s"
+}
+
+resource "exoscale_compute" "vm" {
+  template = %q
+  zone = %q
+  display_name = "terraform-test-compute"
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.key.name}"
+  %%s
+
+  timeouts {
+    delete = "30m"
+  }
+}
+`,
+	defaultExoscaleTemplate,
+	defaultExoscaleZone,
+)
+
+var testAccResourceComputeConfigAffinityGroups = fmt.Sprintf(`
+resource "exoscale_ssh_keypair" "key" {
+  name = "terraform-test-keypair"
+}
+
+resource "exoscale_affinity" "ag1" {
+  name = "terraform-test-affinity1"
+}
+
+resource "exoscale_affinity" "ag2" {
+  name = "terraform-test-affinity2"
+}
+
+resource "exoscale_compute" "vm" {
+  template = %q
+  zone = %q
+  display_name = "terraform-test-compute"
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.key.name}"
+  %%s
+
+  timeouts {
+    delete = "30m"
+  }
+}
+`,
+	defaultExoscaleTemplate,
+	defaultExoscaleZone,
+)
+
+var testAccResourceComputeConfigISO = fmt.Sprintf(`
+resource "exoscale_ssh_keypair" "key" {
+  name = "terraform-test-keypair"
+}
+
+data "exoscale_iso" "netinst" {
+  zone = %q
+  name = %q
+}
+
+resource "exoscale_compute" "vm" {
+  template = %q
+  zone = %q
+  display_name = "terraform-test-compute"
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.key.name}"
+  %%s
+
+  timeouts {
+    delete = "30m"
+  }
+}
+`,
+	defaultExoscaleZone,
+	testAccISO2,
+	defaultExoscaleTemplate,
+	defaultExoscaleZone,
+)
+
+var testAccResourceComputeConfigReverseDNS = fmt.Sprintf(`
+resource "exoscale_ssh_keypair" "key" {
+  name = "terraform-test-keypair"
+}
+
+resource "exoscale_compute" "vm" {
+  template = %q
+  zone = %q
+  display_name = "terraform-test-compute"
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.key.name}"
+  %%s
+
+  timeouts {
+    delete = "30m"
+  }
+}
+`,
+	defaultExoscaleTemplate,
+	defaultExoscaleZone,
+)
+
+var testAccResourceComputeConfigNetworkInterfaces = fmt.Sprintf(`
+resource "exoscale_ssh_keypair" "key" {
+  name = "terraform-test-keypair"
+}
+
+resource "exoscale_network" "managed" {
+  zone = %q
+  network_offering = %q
+  name = "terraform-test-managed"
+
+  start_ip = "10.0.0.1"
+  end_ip = "10.0.0.5"
+  netmask = "255.255.255.0"
+}
+
+resource "exoscale_network" "unmanaged" {
+  zone = %q
+  network_offering = %q
+  name = "terraform-test-unmanaged"
+}
+
+resource "exoscale_network" "nic" {
+  zone = %q
+  network_offering = %q
+  name = "terraform-test-nic"
+}
+
+resource "exoscale_compute" "vm" {
+  template = %q
+  zone = %q
+  display_name = "terraform-test-compute"
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.key.name}"
+  %%s
+
+  timeouts {
+    delete = "30m"
+  }
+}
+
+# Managed outside of the network_interface blocks
+resource "exoscale_nic" "nic" {
+  compute_id = "${exoscale_compute.vm.id}"
+  network_id = "${exoscale_network.nic.id}"
+}
+`,
+	defaultExoscaleZone,
+	defaultExoscaleNetworkOffering,
+	defaultExoscaleZone,
+	defaultExoscaleNetworkOffering,
+	defaultExoscaleZone,
+	defaultExoscaleNetworkOffering,
+	defaultExoscaleTemplate,
+	defaultExoscaleZone,
+)
+
+var testAccResourceComputeConfigRecover = fmt.Sprintf(`
+resource "exoscale_ssh_keypair" "key" {
+  name = "terraform-test-keypair"
+}
+
+resource "exoscale_compute" "vm" {
+  template = %q
+  zone = %q
+  display_name = "terraform-test-compute"
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.key.name}"
+  %%s
+
+  timeouts {
+    delete = "30m"
+  }
+}
+`,
+	defaultExoscaleTemplate,
+	defaultExoscaleZone,
+)
+
+var testAccResourceComputeConfigDeletionProtection = fmt.Sprintf(`
+resource "exoscale_ssh_keypair" "key" {
+  name = "terraform-test-keypair"
+}
+
+resource "exoscale_compute" "vm" {
+  template = %%q
+  zone = %q
+  display_name = "terraform-test-compute"
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.key.name}"
+  deletion_protection = %%t
+
+  timeouts {
+    delete = "30m"
+  }
+}
+`,
+	defaultExoscaleZone,
+)
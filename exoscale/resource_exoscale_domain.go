@@ -13,36 +13,43 @@ func resourceDomainIDString(d resourceIDStringer) string {
 }
 
 func resourceDomain() *schema.Resource {
-	return &schema.Resource{
-		Schema: map[string]*schema.Schema{
-			"name": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
-			},
-			"token": {
-				Type:     schema.TypeString,
-				Computed: true,
-			},
-			"state": {
-				Type:     schema.TypeString,
-				Computed: true,
-			},
-			"auto_renew": {
-				Type:     schema.TypeBool,
-				Computed: true,
-			},
-			"expires_on": {
-				Type:     schema.TypeString,
-				Computed: true,
-			},
+	s := map[string]*schema.Schema{
+		"name": {
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+		},
+		"token": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"state": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"auto_renew": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"expires_on": {
+			Type:     schema.TypeString,
+			Computed: true,
 		},
+	}
+
+	addDeletionProtection(s)
+
+	return &schema.Resource{
+		Schema: s,
 
 		Create: resourceDomainCreate,
 		Read:   resourceDomainRead,
+		Update: resourceDomainUpdate,
 		Delete: resourceDomainDelete,
 		Exists: resourceDomainExists,
 
+		CustomizeDiff: customizeDiffDeletionProtection(s),
+
 		Importer: &schema.ResourceImporter{
 			State: resourceDomainImport,
 		},
@@ -50,6 +57,7 @@ func resourceDomain() *schema.Resource {
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(defaultTimeout),
 			Read:   schema.DefaultTimeout(defaultTimeout),
+			Update: schema.DefaultTimeout(defaultTimeout),
 			Delete: schema.DefaultTimeout(defaultTimeout),
 		},
 	}
@@ -61,7 +69,10 @@ func resourceDomainCreate(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
 	defer cancel()
 
-	client := GetDNSClient(meta)
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		return err
+	}
 
 	domain, err := client.CreateDomain(ctx, d.Get("name").(string))
 	if err != nil {
@@ -79,9 +90,12 @@ func resourceDomainExists(d *schema.ResourceData, meta interface{}) (bool, error
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetDNSClient(meta)
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		return false, err
+	}
 
-	_, err := client.GetDomain(ctx, d.Id())
+	_, err = client.GetDomain(ctx, d.Id())
 	if err != nil {
 		if _, ok := err.(*egoscale.DNSErrorResponse); ok { // nolint: gosimple
 			return false, nil
@@ -97,7 +111,10 @@ func resourceDomainRead(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetDNSClient(meta)
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		return err
+	}
 
 	domain, err := client.GetDomain(ctx, d.Id())
 	if err != nil {
@@ -109,15 +126,32 @@ func resourceDomainRead(d *schema.ResourceData, meta interface{}) error {
 	return resourceDomainApply(d, *domain)
 }
 
+func resourceDomainUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: beginning update", resourceDomainIDString(d))
+
+	// Only deletion_protection can be changed, it isn't held by the API
+
+	log.Printf("[DEBUG] %s: update finished successfully", resourceDomainIDString(d))
+
+	return resourceDomainRead(d, meta)
+}
+
 func resourceDomainDelete(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[DEBUG] %s: beginning delete", resourceDomainIDString(d))
 
+	if err := checkDeletionProtection(d, "exoscale_domain"); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutDelete))
 	defer cancel()
 
-	client := GetDNSClient(meta)
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		return err
+	}
 
-	err := client.DeleteDomain(ctx, d.Id())
+	err = client.DeleteDomain(ctx, d.Id())
 	if err != nil {
 		return err
 	}
@@ -131,7 +165,10 @@ func resourceDomainImport(d *schema.ResourceData, meta interface{}) ([]*schema.R
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetDNSClient(meta)
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		return nil, err
+	}
 	domain, err := client.GetDomain(ctx, d.Id())
 	if err != nil {
 		return nil, err
@@ -141,6 +178,11 @@ func resourceDomainImport(d *schema.ResourceData, meta interface{}) ([]*schema.R
 		return nil, err
 	}
 
+	// Options not held by the API take their default value
+	if err := d.Set("deletion_protection", false); err != nil {
+		return nil, err
+	}
+
 	resources := make([]*schema.ResourceData, 0, 1)
 	resources = append(resources, d)
 
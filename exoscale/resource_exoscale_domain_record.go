@@ -84,7 +84,10 @@ func resourceDomainRecordCreate(d *schema.ResourceData, meta interface{}) error
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
 	defer cancel()
 
-	client := GetDNSClient(meta)
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		return err
+	}
 
 	record, err := client.CreateRecord(ctx, d.Get("domain").(string), egoscale.DNSRecord{
 		Name:       d.Get("name").(string),
@@ -109,7 +112,10 @@ func resourceDomainRecordExists(d *schema.ResourceData, meta interface{}) (bool,
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetDNSClient(meta)
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		return false, err
+	}
 
 	id, _ := strconv.ParseInt(d.Id(), 10, 64)
 	domain := d.Get("domain").(string)
@@ -156,7 +162,10 @@ func resourceDomainRecordRead(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetDNSClient(meta)
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, _ := strconv.ParseInt(d.Id(), 10, 64)
 	domain := d.Get("domain").(string)
@@ -204,7 +213,10 @@ func resourceDomainRecordUpdate(d *schema.ResourceData, meta interface{}) error
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutUpdate))
 	defer cancel()
 
-	client := GetDNSClient(meta)
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, _ := strconv.ParseInt(d.Id(), 10, 64)
 	record, err := client.UpdateRecord(ctx, d.Get("domain").(string), egoscale.UpdateDNSRecord{
@@ -230,7 +242,10 @@ func resourceDomainRecordDelete(d *schema.ResourceData, meta interface{}) error
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutDelete))
 	defer cancel()
 
-	client := GetDNSClient(meta)
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, _ := strconv.ParseInt(d.Id(), 10, 64)
 	if err := client.DeleteRecord(ctx, d.Get("domain").(string), id); err != nil {
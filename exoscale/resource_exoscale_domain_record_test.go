@@ -71,6 +71,88 @@ func TestAccResourceDomainRecord(t *testing.T) {
 	})
 }
 
+func TestResourceDomainRecordReadWithoutDomain(t *testing.T) {
+	api, meta := newMockDNSConfig()
+	defer api.Close()
+
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateDomain(context.TODO(), testDomain); err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := client.CreateRecord(context.TODO(), testDomain, egoscale.DNSRecord{
+		Name:       "mail1",
+		RecordType: "MX",
+		Content:    "mta1",
+		Prio:       10,
+		TTL:        10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Imported records only know their ID, the domain is looked up
+	d := resourceDomainRecord().TestResourceData()
+	d.SetId(strconv.FormatInt(record.ID, 10))
+
+	if err := resourceDomainRecordRead(d, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkResourceAttributes(testAttrs{
+		"domain":      ValidateString(testDomain),
+		"name":        ValidateString("mail1"),
+		"record_type": ValidateString("MX"),
+		"content":     ValidateString("mta1"),
+		"prio":        ValidateString("10"),
+		"ttl":         ValidateString("10"),
+	}, d.State().Attributes); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestResourceDomainRecordCreateInvalid(t *testing.T) {
+	api, meta := newMockDNSConfig()
+	defer api.Close()
+
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateDomain(context.TODO(), testDomain); err != nil {
+		t.Fatal(err)
+	}
+
+	d := resourceDomainRecord().TestResourceData()
+	for k, v := range map[string]interface{}{
+		"domain":      testDomain,
+		"name":        "www",
+		"record_type": "A",
+		"content":     "",
+	} {
+		if err := d.Set(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = resourceDomainRecordCreate(d, meta)
+	e, ok := err.(*egoscale.DNSErrorResponse)
+	if !ok {
+		t.Fatalf("expected a DNS error response, got %#v", err)
+	}
+
+	if len(e.Errors["content"]) == 0 {
+		t.Errorf("expected an error on the content field, got %v", e)
+	}
+
+	if d.Id() != "" {
+		t.Errorf("expected no resource ID, got %q", d.Id())
+	}
+}
+
 func testAccCheckResourceDomainRecordExists(n string, domain *egoscale.DNSDomain, record *egoscale.DNSRecord) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -84,7 +166,10 @@ func testAccCheckResourceDomainRecordExists(n string, domain *egoscale.DNSDomain
 
 		id, _ := strconv.ParseInt(rs.Primary.ID, 10, 64)
 
-		client := GetDNSClient(testAccProvider.Meta())
+		client, err := GetDNSClient(testAccProvider.Meta())
+		if err != nil {
+			return err
+		}
 		r, err := client.GetRecord(context.TODO(), domain.Name, id)
 		if err != nil {
 			return err
@@ -121,7 +206,10 @@ func testAccCheckResourceDomainRecordAttributes(expected testAttrs) resource.Tes
 }
 
 func testAccCheckResourceDomainRecordDestroy(s *terraform.State) error {
-	client := GetDNSClient(testAccProvider.Meta())
+	client, err := GetDNSClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "exoscale_domain_record" {
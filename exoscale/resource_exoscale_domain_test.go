@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/exoscale/egoscale"
@@ -15,6 +18,45 @@ const (
 	testDomain = "terraform-test.exo"
 )
 
+func init() {
+	resource.AddTestSweepers("exoscale_domain", &resource.Sweeper{
+		Name: "exoscale_domain",
+		F:    testSweepDomain,
+	})
+}
+
+func testSweepDomain(zoneName string) error {
+	config, err := testSweeperConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := GetDNSClient(config)
+	if err != nil {
+		return err
+	}
+
+	domains, err := client.GetDomains(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, domain := range domains {
+		if !strings.HasPrefix(domain.Name, testPrefix) {
+			continue
+		}
+
+		log.Printf("[INFO] sweeping exoscale_domain %s", domain.Name)
+		if err := client.DeleteDomain(ctx, domain.Name); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to delete domain %s: %s", domain.Name, err))
+		}
+	}
+
+	return testSweepErrors(errs)
+}
+
 func TestAccResourceDomain(t *testing.T) {
 	domain := new(egoscale.DNSDomain)
 
@@ -57,6 +99,127 @@ func TestAccResourceDomain(t *testing.T) {
 	})
 }
 
+func TestAccResourceDomainDeletionProtection(t *testing.T) {
+	domain := new(egoscale.DNSDomain)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceDomainDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccDNSDomainConfigDeletionProtection, testDomain, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceDomainExists("exoscale_domain.exo", domain),
+					resource.TestCheckResourceAttr("exoscale_domain.exo", "deletion_protection", "true"),
+				),
+			},
+			{
+				// The protection must be disabled by a separate apply
+				Config:      fmt.Sprintf(testAccDNSDomainConfigDeletionProtection, "other-"+testDomain, false),
+				ExpectError: regexp.MustCompile(`deletion protection is enabled, changing "name" would replace the resource`),
+			},
+			{
+				Config:      fmt.Sprintf(testAccDNSDomainConfigDeletionProtection, testDomain, true),
+				Destroy:     true,
+				ExpectError: regexp.MustCompile("deletion protection is enabled"),
+			},
+			{
+				Config: fmt.Sprintf(testAccDNSDomainConfigDeletionProtection, testDomain, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceDomainExists("exoscale_domain.exo", domain),
+					resource.TestCheckResourceAttr("exoscale_domain.exo", "deletion_protection", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestResourceDomainExists(t *testing.T) {
+	api, meta := newMockDNSConfig()
+	defer api.Close()
+
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateDomain(context.TODO(), testDomain); err != nil {
+		t.Fatal(err)
+	}
+
+	d := resourceDomain().TestResourceData()
+
+	d.SetId(testDomain)
+	exists, err := resourceDomainExists(d, meta)
+	if err != nil || !exists {
+		t.Errorf("expected domain %q to exist, got %v (%v)", testDomain, exists, err)
+	}
+
+	d.SetId("missing." + testDomain)
+	exists, err = resourceDomainExists(d, meta)
+	if err != nil || exists {
+		t.Errorf("expected domain %q not to exist, got %v (%v)", d.Id(), exists, err)
+	}
+}
+
+func TestResourceDomainImport(t *testing.T) {
+	api, meta := newMockDNSConfig()
+	defer api.Close()
+
+	client, err := GetDNSClient(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateDomain(context.TODO(), testDomain); err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := client.CreateRecord(context.TODO(), testDomain, egoscale.DNSRecord{
+		Name:       "www",
+		RecordType: "A",
+		Content:    "192.0.2.1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := resourceDomain().TestResourceData()
+	d.SetId(testDomain)
+
+	resources, err := resourceDomainImport(d, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The default SOA and NS records must not be imported
+	if len(resources) != 2 {
+		t.Fatalf("expected 2 imported resources, got %d", len(resources))
+	}
+
+	if err := checkResourceAttributes(testAttrs{
+		"name":  ValidateString(testDomain),
+		"state": ValidateString("hosted"),
+		"token": ValidateRegexp("^[0-9a-f]{32}$"),
+	}, resources[0].State().Attributes); err != nil {
+		t.Error(err)
+	}
+
+	if resources[1].Id() != fmt.Sprintf("%d", record.ID) {
+		t.Errorf("expected record ID %d, got %s", record.ID, resources[1].Id())
+	}
+
+	if err := checkResourceAttributes(testAttrs{
+		"domain":      ValidateString(testDomain),
+		"name":        ValidateString("www"),
+		"record_type": ValidateString("A"),
+		"content":     ValidateString("192.0.2.1"),
+		"ttl":         ValidateString("3600"),
+		"hostname":    ValidateString("www." + testDomain),
+	}, resources[1].State().Attributes); err != nil {
+		t.Error(err)
+	}
+}
+
 func testAccCheckResourceDomainExists(n string, domain *egoscale.DNSDomain) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -68,7 +231,10 @@ func testAccCheckResourceDomainExists(n string, domain *egoscale.DNSDomain) reso
 			return errors.New("resource ID not set")
 		}
 
-		client := GetDNSClient(testAccProvider.Meta())
+		client, err := GetDNSClient(testAccProvider.Meta())
+		if err != nil {
+			return err
+		}
 		d, err := client.GetDomain(context.TODO(), rs.Primary.ID)
 		if err != nil {
 			return err
@@ -105,7 +271,10 @@ func testAccCheckResourceDomainAttributes(expected testAttrs) resource.TestCheck
 }
 
 func testAccCheckResourceDomainDestroy(s *terraform.State) error {
-	client := GetDNSClient(testAccProvider.Meta())
+	client, err := GetDNSClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "exoscale_domain" {
@@ -133,3 +302,10 @@ resource "exoscale_domain" "exo" {
 }
 `,
 	testDomain)
+
+var testAccDNSDomainConfigDeletionProtection = `
+resource "exoscale_domain" "exo" {
+  name = %q
+  deletion_protection = %t
+}
+`
@@ -0,0 +1,392 @@
+package exoscale
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/exoscale/egoscale"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+// eipServiceCloudInit binds the elastic IP address on the loopback interface
+// of the members, so that they accept the traffic sent to it
+const eipServiceCloudInit = `#cloud-config
+bootcmd:
+  - [ ip, address, add, %s/32, dev, lo ]
+`
+
+func resourceEIPServiceIDString(d resourceIDStringer) string {
+	return resourceIDString(d, "exoscale_eip_service")
+}
+
+func resourceEIPService() *schema.Resource {
+	s := map[string]*schema.Schema{
+		"zone": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "Name of the zone (by default: the provider zone)",
+		},
+		"healthcheck": {
+			Type:     schema.TypeList,
+			Required: true,
+			MaxItems: 1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"mode": {
+						Type:         schema.TypeString,
+						Required:     true,
+						Description:  "Healthcheck probing mode",
+						ValidateFunc: validateHealthcheckMode,
+					},
+					"port": {
+						Type:         schema.TypeInt,
+						Required:     true,
+						Description:  "Healthcheck service port to probe",
+						ValidateFunc: validation.IntBetween(1, 65535),
+					},
+					"path": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Healthcheck probe HTTP request path, must be specified in \"http\" mode",
+					},
+					"interval": {
+						Type:         schema.TypeInt,
+						Optional:     true,
+						Default:      10,
+						Description:  "Healthcheck probing interval in seconds",
+						ValidateFunc: validation.IntBetween(5, 300),
+					},
+					"timeout": {
+						Type:         schema.TypeInt,
+						Optional:     true,
+						Default:      2,
+						Description:  "Time in seconds before considering a healthcheck probing failed",
+						ValidateFunc: validation.IntBetween(2, 60),
+					},
+					"strikes_ok": {
+						Type:         schema.TypeInt,
+						Optional:     true,
+						Default:      3,
+						Description:  "Number of successful healthcheck probes before considering the target healthy",
+						ValidateFunc: validation.IntBetween(1, 20),
+					},
+					"strikes_fail": {
+						Type:         schema.TypeInt,
+						Optional:     true,
+						Default:      3,
+						Description:  "Number of unsuccessful healthcheck probes before considering the target unhealthy",
+						ValidateFunc: validation.IntBetween(1, 20),
+					},
+				},
+			},
+		},
+		"compute_ids": {
+			Type:        schema.TypeSet,
+			Optional:    true,
+			Set:         schema.HashString,
+			Description: "IDs of the Compute instances serving the Elastic IP address",
+			Elem: &schema.Schema{
+				Type:         schema.TypeString,
+				ValidateFunc: ValidateUUID(),
+			},
+		},
+		"ip_address": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"cloud_init": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "cloud-init configuration binding the Elastic IP address on the members loopback interface",
+		},
+	}
+
+	addTags(s, "tags")
+
+	return &schema.Resource{
+		Schema: s,
+
+		Create: resourceEIPServiceCreate,
+		Read:   resourceEIPServiceRead,
+		Update: resourceEIPServiceUpdate,
+		Delete: resourceEIPServiceDelete,
+		Exists: resourceEIPServiceExists,
+
+		CustomizeDiff: resourceEIPServiceCustomizeDiff,
+
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Update: schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
+		},
+	}
+}
+
+func resourceEIPServiceCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if err := customizeDiffTags("tags")(d, meta); err != nil {
+		return err
+	}
+
+	// The values only known at apply time are skipped
+	if !d.NewValueKnown("healthcheck.0.mode") {
+		return nil
+	}
+
+	return validateHealthcheckProbe(d, "healthcheck.0.", d.Get("healthcheck.0.mode").(string))
+}
+
+func resourceEIPServiceCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: beginning create", resourceEIPServiceIDString(d))
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
+
+	zone, err := getZoneByName(ctx, client, d.Get("zone").(string))
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.RequestWithContext(ctx, &egoscale.AssociateIPAddress{
+		ZoneID:                 zone.ID,
+		HealthcheckMode:        d.Get("healthcheck.0.mode").(string),
+		HealthcheckPort:        int64(d.Get("healthcheck.0.port").(int)),
+		HealthcheckPath:        d.Get("healthcheck.0.path").(string),
+		HealthcheckInterval:    int64(d.Get("healthcheck.0.interval").(int)),
+		HealthcheckTimeout:     int64(d.Get("healthcheck.0.timeout").(int)),
+		HealthcheckStrikesOk:   int64(d.Get("healthcheck.0.strikes_ok").(int)),
+		HealthcheckStrikesFail: int64(d.Get("healthcheck.0.strikes_fail").(int)),
+	})
+	if err != nil {
+		return err
+	}
+
+	elasticIP := resp.(*egoscale.IPAddress)
+
+	d.SetId(elasticIP.ID.String())
+
+	cmd, err := createTags(d, "tags", elasticIP.ResourceType(), meta)
+	if err != nil {
+		return err
+	}
+
+	if cmd != nil {
+		if err := client.BooleanRequestWithContext(ctx, cmd); err != nil {
+			// Attempting to release the freshly created elastic IP address
+			if e := client.BooleanRequestWithContext(ctx, &egoscale.DisassociateIPAddress{
+				ID: elasticIP.ID,
+			}); e != nil {
+				log.Printf("[WARNING] Failure to create the tags, but the elastic IP address was created. %v", e)
+			}
+
+			return err
+		}
+	}
+
+	if err := syncIPAddressAssociation(ctx, client, elasticIP, d.Get("compute_ids").(*schema.Set)); err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] %s: create finished successfully", resourceEIPServiceIDString(d))
+
+	return resourceEIPServiceRead(d, meta)
+}
+
+func resourceEIPServiceExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = getIPAddressAssociation(ctx, client, d.Id())
+	if err != nil {
+		e := handleNotFound(d, err)
+		return d.Id() != "", e
+	}
+
+	return true, nil
+}
+
+func resourceEIPServiceRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: beginning read", resourceEIPServiceIDString(d))
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
+
+	elasticIP, err := getIPAddressAssociation(ctx, client, d.Id())
+	if err != nil {
+		return handleNotFound(d, err)
+	}
+
+	members, err := getIPAddressAssociationMembers(ctx, client, elasticIP)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] %s: read finished successfully", resourceEIPServiceIDString(d))
+
+	return resourceEIPServiceApply(d, elasticIP, members, meta)
+}
+
+func resourceEIPServiceUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: beginning update", resourceEIPServiceIDString(d))
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
+
+	elasticIP, err := getIPAddressAssociation(ctx, client, d.Id())
+	if err != nil {
+		return err
+	}
+
+	d.Partial(true)
+
+	if d.HasChange("healthcheck") {
+		if _, err := client.RequestWithContext(ctx, &egoscale.UpdateIPAddress{
+			ID:                     elasticIP.ID,
+			HealthcheckMode:        d.Get("healthcheck.0.mode").(string),
+			HealthcheckPort:        int64(d.Get("healthcheck.0.port").(int)),
+			HealthcheckPath:        d.Get("healthcheck.0.path").(string),
+			HealthcheckInterval:    int64(d.Get("healthcheck.0.interval").(int)),
+			HealthcheckTimeout:     int64(d.Get("healthcheck.0.timeout").(int)),
+			HealthcheckStrikesOk:   int64(d.Get("healthcheck.0.strikes_ok").(int)),
+			HealthcheckStrikesFail: int64(d.Get("healthcheck.0.strikes_fail").(int)),
+		}); err != nil {
+			return err
+		}
+
+		d.SetPartial("healthcheck")
+	}
+
+	if d.HasChange("compute_ids") {
+		if err := syncIPAddressAssociation(ctx, client, elasticIP, d.Get("compute_ids").(*schema.Set)); err != nil {
+			return err
+		}
+
+		d.SetPartial("compute_ids")
+	}
+
+	updates, err := updateTags(d, "tags", elasticIP.ResourceType(), meta)
+	if err != nil {
+		return err
+	}
+	for _, update := range updates {
+		if err := client.BooleanRequestWithContext(ctx, update); err != nil {
+			return err
+		}
+	}
+
+	d.Partial(false)
+
+	log.Printf("[DEBUG] %s: update finished successfully", resourceEIPServiceIDString(d))
+
+	return resourceEIPServiceRead(d, meta)
+}
+
+func resourceEIPServiceDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: beginning delete", resourceEIPServiceIDString(d))
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutDelete))
+	defer cancel()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
+
+	elasticIP, err := getIPAddressAssociation(ctx, client, d.Id())
+	if err != nil {
+		// the elastic IP address is already gone
+		if e := handleNotFound(d, err); e == nil {
+			return nil
+		}
+		return err
+	}
+
+	// the members are detached first, the elastic IP address cannot be
+	// released while it is in use
+	if err := syncIPAddressAssociation(ctx, client, elasticIP, schema.NewSet(schema.HashString, nil)); err != nil {
+		return err
+	}
+
+	if err := client.DeleteWithContext(ctx, &egoscale.IPAddress{ID: elasticIP.ID}); err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] %s: delete finished successfully", resourceEIPServiceIDString(d))
+
+	return nil
+}
+
+func resourceEIPServiceApply(d *schema.ResourceData, elasticIP *egoscale.IPAddress, members map[string]egoscale.NicSecondaryIP, meta interface{}) error {
+	d.SetId(elasticIP.ID.String())
+
+	if err := d.Set("ip_address", elasticIP.IPAddress.String()); err != nil {
+		return err
+	}
+	if err := d.Set("zone", elasticIP.ZoneName); err != nil {
+		return err
+	}
+	if err := d.Set("cloud_init", fmt.Sprintf(eipServiceCloudInit, elasticIP.IPAddress)); err != nil {
+		return err
+	}
+
+	// a healthcheck removed outside of Terraform is configured again
+	healthcheck := make([]map[string]interface{}, 0, 1)
+	if elasticIP.Healthcheck != nil {
+		path := ""
+		// the path is only meaningful in "http" mode
+		if elasticIP.Healthcheck.Mode == "http" {
+			path = elasticIP.Healthcheck.Path
+		}
+
+		healthcheck = append(healthcheck, map[string]interface{}{
+			"mode":         elasticIP.Healthcheck.Mode,
+			"port":         int(elasticIP.Healthcheck.Port),
+			"path":         path,
+			"interval":     int(elasticIP.Healthcheck.Interval),
+			"timeout":      int(elasticIP.Healthcheck.Timeout),
+			"strikes_ok":   int(elasticIP.Healthcheck.StrikesOk),
+			"strikes_fail": int(elasticIP.Healthcheck.StrikesFail),
+		})
+	}
+	if err := d.Set("healthcheck", healthcheck); err != nil {
+		return err
+	}
+
+	if err := setTags(d, "tags", elasticIP.Tags, meta); err != nil {
+		return err
+	}
+
+	ids := make([]interface{}, 0, len(members))
+	for id := range members {
+		ids = append(ids, id)
+	}
+
+	return d.Set("compute_ids", schema.NewSet(schema.HashString, ids))
+}
@@ -0,0 +1,289 @@
+package exoscale
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/exoscale/egoscale"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccResourceEIPService(t *testing.T) {
+	vm1 := new(egoscale.VirtualMachine)
+	vm2 := new(egoscale.VirtualMachine)
+	eip := new(egoscale.IPAddress)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceEIPServiceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceEIPServiceConfig, `
+  healthcheck {
+    mode = "http"
+    port = 80
+  }
+`, ""),
+				ExpectError: regexp.MustCompile(`healthcheck.0.path must be specified in "http" mode`),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceEIPServiceConfig, `
+  healthcheck {
+    mode = "tcp"
+    port = 22
+  }
+`, `"${exoscale_compute.vm1.id}"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm1", vm1),
+					testAccCheckResourceComputeExists("exoscale_compute.vm2", vm2),
+					testAccCheckIPAddressExists("exoscale_eip_service.svc", eip),
+					testAccCheckResourceEIPServiceHealthcheck(eip, egoscale.Healthcheck{
+						Mode:        "tcp",
+						Port:        22,
+						Interval:    10,
+						Timeout:     2,
+						StrikesOk:   3,
+						StrikesFail: 3,
+					}),
+					testAccCheckResourceIPAddressAssociation(eip, vm1),
+					testAccCheckResourceEIPServiceCloudInit(eip),
+					testAccCheckResourceEIPServiceAttributes(testAttrs{
+						"zone":          ValidateString(defaultExoscaleZone),
+						"ip_address":    ValidateIPv4String,
+						"compute_ids.#": ValidateString("1"),
+						"tags.test":     ValidateString("acceptance"),
+					}),
+					func(s *terraform.State) error {
+						if len(eip.Tags) != 1 || eip.Tags[0].Key != "test" || eip.Tags[0].Value != "acceptance" {
+							return fmt.Errorf("expected the elastic IP address to be tagged, got %v", eip.Tags)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceEIPServiceConfig, `
+  healthcheck {
+    mode = "http"
+    port = 8080
+    path = "/health"
+    interval = 20
+    timeout = 5
+    strikes_ok = 2
+    strikes_fail = 4
+  }
+`, `"${exoscale_compute.vm1.id}", "${exoscale_compute.vm2.id}"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceEIPServiceInPlace("exoscale_eip_service.svc", eip),
+					testAccCheckIPAddressExists("exoscale_eip_service.svc", eip),
+					testAccCheckResourceEIPServiceHealthcheck(eip, egoscale.Healthcheck{
+						Mode:        "http",
+						Port:        8080,
+						Path:        "/health",
+						Interval:    20,
+						Timeout:     5,
+						StrikesOk:   2,
+						StrikesFail: 4,
+					}),
+					testAccCheckResourceIPAddressAssociation(eip, vm1, vm2),
+					testAccCheckResourceEIPServiceAttributes(testAttrs{
+						"compute_ids.#": ValidateString("2"),
+					}),
+				),
+			},
+			{
+				ResourceName:      "exoscale_eip_service.svc",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateCheck: func(s []*terraform.InstanceState) error {
+					return checkResourceAttributes(
+						testAttrs{
+							"healthcheck.0.mode": ValidateString("http"),
+							"compute_ids.#":      ValidateString("2"),
+						},
+						s[0].Attributes)
+				},
+			},
+		},
+	})
+}
+
+func TestAccResourceEIPServiceHealthcheckUnknown(t *testing.T) {
+	eip := new(egoscale.IPAddress)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceEIPServiceDestroy,
+		Steps: []resource.TestStep{
+			{
+				// The interval is only known at apply time, it isn't checked
+				// against the timeout when planning
+				Config: fmt.Sprintf(testAccResourceEIPServiceConfig, `
+  healthcheck {
+    mode = "tcp"
+    port = 22
+    interval = length(exoscale_ssh_keypair.key.fingerprint) - 40
+    timeout = 5
+  }
+`, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckIPAddressExists("exoscale_eip_service.svc", eip),
+					testAccCheckResourceEIPServiceHealthcheck(eip, egoscale.Healthcheck{
+						Mode:        "tcp",
+						Port:        22,
+						Interval:    7,
+						Timeout:     5,
+						StrikesOk:   3,
+						StrikesFail: 3,
+					}),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckResourceEIPServiceInPlace checks that the elastic IP address of
+// the previous step wasn't replaced
+func testAccCheckResourceEIPServiceInPlace(n string, eip *egoscale.IPAddress) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return errors.New("resource not found in the state")
+		}
+
+		if rs.Primary.ID != eip.ID.String() {
+			return fmt.Errorf("expected the elastic IP address %s to be updated in place, got %s", eip.ID, rs.Primary.ID)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckResourceEIPServiceHealthcheck(eip *egoscale.IPAddress, expected egoscale.Healthcheck) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if eip.Healthcheck == nil {
+			return errors.New("healthcheck is nil")
+		}
+
+		if *eip.Healthcheck != expected {
+			return fmt.Errorf("expected healthcheck %+v, got %+v", expected, *eip.Healthcheck)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckResourceEIPServiceCloudInit(eip *egoscale.IPAddress) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "exoscale_eip_service" {
+				continue
+			}
+
+			cloudInit := rs.Primary.Attributes["cloud_init"]
+			if !strings.HasPrefix(cloudInit, "#cloud-config\n") {
+				return fmt.Errorf("cloud_init: expected a cloud-config, got %q", cloudInit)
+			}
+			if !strings.Contains(cloudInit, fmt.Sprintf("%s/32, dev, lo", eip.IPAddress)) {
+				return fmt.Errorf("cloud_init: expected %s to be bound on lo, got %q", eip.IPAddress, cloudInit)
+			}
+
+			return nil
+		}
+
+		return errors.New("resource not found in the state")
+	}
+}
+
+func testAccCheckResourceEIPServiceAttributes(expected testAttrs) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "exoscale_eip_service" {
+				continue
+			}
+
+			return checkResourceAttributes(expected, rs.Primary.Attributes)
+		}
+
+		return errors.New("resource not found in the state")
+	}
+}
+
+func testAccCheckResourceEIPServiceDestroy(s *terraform.State) error {
+	client, err := GetComputeClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "exoscale_eip_service" {
+			continue
+		}
+
+		id, err := egoscale.ParseUUID(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = client.Get(&egoscale.IPAddress{
+			ID:        id,
+			IsElastic: true,
+		})
+		if err != nil {
+			if r, ok := err.(*egoscale.ErrorResponse); ok {
+				if r.ErrorCode == egoscale.ParamError {
+					return nil
+				}
+			}
+			return err
+		}
+		return errors.New("IP address still exists")
+	}
+	return nil
+}
+
+var testAccResourceEIPServiceConfig = fmt.Sprintf(`
+resource "exoscale_ssh_keypair" "key" {
+  name = "terraform-test-keypair"
+}
+
+resource "exoscale_compute" "vm1" {
+  display_name = "terraform-test-compute1"
+  template = %q
+  zone = %q
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.key.name}"
+}
+
+resource "exoscale_compute" "vm2" {
+  display_name = "terraform-test-compute2"
+  template = %q
+  zone = %q
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.key.name}"
+}
+
+resource "exoscale_eip_service" "svc" {
+  zone = %q
+  %%s
+  compute_ids = [%%s]
+
+  tags = {
+    test = "acceptance"
+  }
+}
+`,
+	defaultExoscaleTemplate,
+	defaultExoscaleZone,
+	defaultExoscaleTemplate,
+	defaultExoscaleZone,
+	defaultExoscaleZone,
+)
@@ -2,7 +2,6 @@ package exoscale
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -21,16 +20,15 @@ func resourceIPAddress() *schema.Resource {
 	s := map[string]*schema.Schema{
 		"zone": {
 			Type:        schema.TypeString,
-			Required:    true,
+			Optional:    true,
 			ForceNew:    true,
-			Description: "Name of the zone",
+			Description: "Name of the zone (by default: the provider zone)",
 		},
 		"healthcheck_mode": {
 			Type:         schema.TypeString,
 			Description:  "Healthcheck probing mode",
 			Optional:     true,
-			ValidateFunc: validation.StringMatch(regexp.MustCompile("(?:tcp|http)"), `must be either "tcp" or "http"`),
-			ForceNew:     true,
+			ValidateFunc: validateHealthcheckMode,
 		},
 		"healthcheck_port": {
 			Type:         schema.TypeInt,
@@ -71,9 +69,17 @@ func resourceIPAddress() *schema.Resource {
 			Type:     schema.TypeString,
 			Computed: true,
 		},
+		"reverse_dns": {
+			Type:             schema.TypeString,
+			Optional:         true,
+			ValidateFunc:     ValidateFQDN,
+			DiffSuppressFunc: suppressReverseDNSDiff,
+			Description:      "Domain name of the PTR record of the IP address",
+		},
 	}
 
 	addTags(s, "tags")
+	addDeletionProtection(s)
 
 	return &schema.Resource{
 		Schema: s,
@@ -84,8 +90,10 @@ func resourceIPAddress() *schema.Resource {
 		Delete: resourceIPAddressDelete,
 		Exists: resourceIPAddressExists,
 
+		CustomizeDiff: resourceIPAddressCustomizeDiff(customizeDiffDeletionProtection(s)),
+
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			State: importStateDeletionProtection,
 		},
 
 		Timeouts: &schema.ResourceTimeout{
@@ -97,67 +105,128 @@ func resourceIPAddress() *schema.Resource {
 	}
 }
 
-func resourceIPAddressCreate(d *schema.ResourceData, meta interface{}) error {
-	log.Printf("[DEBUG] %s: beginning create", resourceIPAddressIDString(d))
-
-	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
-	defer cancel()
+// resourceIPAddressHealthcheckKeys are the healthcheck settings, besides the
+// healthcheck_mode
+var resourceIPAddressHealthcheckKeys = []string{
+	"healthcheck_port",
+	"healthcheck_path",
+	"healthcheck_interval",
+	"healthcheck_timeout",
+	"healthcheck_strikes_ok",
+	"healthcheck_strikes_fail",
+}
 
-	client := GetComputeClient(meta)
+// resourceIPAddressCustomizeDiff is built along with the schema, so is the
+// given deletion protection check
+func resourceIPAddressCustomizeDiff(deletionProtection schema.CustomizeDiffFunc) schema.CustomizeDiffFunc {
+	return func(d *schema.ResourceDiff, meta interface{}) error {
+		if err := customizeDiffTags("tags")(d, meta); err != nil {
+			return err
+		}
 
-	zoneName := d.Get("zone").(string)
+		if err := deletionProtection(d, meta); err != nil {
+			return err
+		}
 
-	zone, err := getZoneByName(ctx, client, zoneName)
-	if err != nil {
-		return err
+		return resourceIPAddressCustomizeDiffHealthcheck(d)
 	}
+}
 
-	req := &egoscale.AssociateIPAddress{ZoneID: zone.ID}
+func resourceIPAddressCustomizeDiffHealthcheck(d *schema.ResourceDiff) error {
+	return resourceIPAddressValidateHealthcheck(d)
+}
 
-	if req.HealthcheckMode = d.Get("healthcheck_mode").(string); req.HealthcheckMode != "" {
-		if req.HealthcheckPort = int64(d.Get("healthcheck_port").(int)); req.HealthcheckPort == 0 {
-			return errors.New("healthcheck_port must be specified")
-		}
+// resourceIPAddressValidateHealthcheck checks the healthcheck settings against
+// the healthcheck_mode, the values only known at apply time are skipped
+func resourceIPAddressValidateHealthcheck(d *schema.ResourceDiff) error {
+	if !d.NewValueKnown("healthcheck_mode") {
+		return nil
+	}
 
-		req.HealthcheckPath = d.Get("healthcheck_path").(string)
-		if req.HealthcheckMode == "http" && req.HealthcheckPath == "" {
-			return errors.New("healthcheck_path must be specified in \"http\" mode")
-		} else if req.HealthcheckMode == "tcp" && req.HealthcheckPath != "" {
-			return errors.New("healthcheck_path must not be specified in \"tcp\" mode")
+	mode := d.Get("healthcheck_mode").(string)
+	if mode == "" {
+		for _, k := range resourceIPAddressHealthcheckKeys {
+			if _, ok := d.GetOk(k); ok {
+				return fmt.Errorf("%q can only be specified with healthcheck_mode", k)
+			}
 		}
 
-		if req.HealthcheckInterval = int64(d.Get("healthcheck_interval").(int)); req.HealthcheckInterval == 0 {
-			return errors.New("healthcheck_interval must be specified")
-		}
+		return nil
+	}
 
-		if req.HealthcheckTimeout = int64(d.Get("healthcheck_timeout").(int)); req.HealthcheckTimeout == 0 {
-			return errors.New("healthcheck_timeout must be specified")
-		} else if req.HealthcheckTimeout >= req.HealthcheckInterval {
-			return errors.New("healthcheck_timeout must be lower than healthcheck_interval")
+	for _, k := range []string{
+		"healthcheck_port",
+		"healthcheck_interval",
+		"healthcheck_timeout",
+		"healthcheck_strikes_ok",
+		"healthcheck_strikes_fail",
+	} {
+		if d.NewValueKnown(k) && d.Get(k).(int) == 0 {
+			return fmt.Errorf("%s must be specified", k)
 		}
+	}
 
-		if req.HealthcheckStrikesOk = int64(d.Get("healthcheck_strikes_ok").(int)); req.HealthcheckStrikesOk == 0 {
-			return errors.New("healthcheck_strikes_ok must be specified")
-		}
+	return validateHealthcheckProbe(d, "healthcheck_", mode)
+}
 
-		if req.HealthcheckStrikesFail = int64(d.Get("healthcheck_strikes_fail").(int)); req.HealthcheckStrikesFail == 0 {
-			return errors.New("healthcheck_strikes_fail must be specified")
+// validateHealthcheckMode checks the healthcheck probing mode of the Elastic
+// IP resources
+var validateHealthcheckMode = validation.StringMatch(regexp.MustCompile("^(?:tcp|http)$"), `must be either "tcp" or "http"`)
+
+// validateHealthcheckProbe checks the healthcheck path, interval and timeout,
+// named after the given prefix, against the mode. The values only known at
+// apply time are skipped.
+func validateHealthcheckProbe(d *schema.ResourceDiff, prefix, mode string) error {
+	pathKey := prefix + "path"
+	if d.NewValueKnown(pathKey) {
+		path := d.Get(pathKey).(string)
+		if mode == "http" && path == "" {
+			return fmt.Errorf("%s must be specified in \"http\" mode", pathKey)
+		} else if mode == "tcp" && path != "" {
+			return fmt.Errorf("%s must not be specified in \"tcp\" mode", pathKey)
 		}
-	} else {
-		for _, k := range []string{
-			"healthcheck_port",
-			"healthcheck_path",
-			"healthcheck_interval",
-			"healthcheck_timeout",
-			"healthcheck_strikes_ok",
-			"healthcheck_strikes_fail",
-		} {
-			if _, ok := d.GetOkExists(k); ok {
-				return fmt.Errorf("%q can only be specified with healthcheck_mode", k)
-			}
+	}
+
+	timeoutKey := prefix + "timeout"
+	intervalKey := prefix + "interval"
+	if d.NewValueKnown(timeoutKey) && d.NewValueKnown(intervalKey) {
+		if d.Get(timeoutKey).(int) >= d.Get(intervalKey).(int) {
+			return fmt.Errorf("%s must be lower than %s", timeoutKey, intervalKey)
 		}
 	}
 
+	return nil
+}
+
+func resourceIPAddressCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: beginning create", resourceIPAddressIDString(d))
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
+
+	zoneName := d.Get("zone").(string)
+
+	zone, err := getZoneByName(ctx, client, zoneName)
+	if err != nil {
+		return err
+	}
+
+	req := &egoscale.AssociateIPAddress{
+		ZoneID:                 zone.ID,
+		HealthcheckMode:        d.Get("healthcheck_mode").(string),
+		HealthcheckPort:        int64(d.Get("healthcheck_port").(int)),
+		HealthcheckPath:        d.Get("healthcheck_path").(string),
+		HealthcheckInterval:    int64(d.Get("healthcheck_interval").(int)),
+		HealthcheckTimeout:     int64(d.Get("healthcheck_timeout").(int)),
+		HealthcheckStrikesOk:   int64(d.Get("healthcheck_strikes_ok").(int)),
+		HealthcheckStrikesFail: int64(d.Get("healthcheck_strikes_fail").(int)),
+	}
+
 	resp, err := client.RequestWithContext(ctx, req)
 	if err != nil {
 		return err
@@ -171,7 +240,7 @@ func resourceIPAddressCreate(d *schema.ResourceData, meta interface{}) error {
 		return err
 	}
 
-	cmd, err := createTags(d, "tags", elasticIP.ResourceType())
+	cmd, err := createTags(d, "tags", elasticIP.ResourceType(), meta)
 	if err != nil {
 		return err
 	}
@@ -191,6 +260,15 @@ func resourceIPAddressCreate(d *schema.ResourceData, meta interface{}) error {
 		}
 	}
 
+	if reverseDNS := d.Get("reverse_dns").(string); reverseDNS != "" {
+		if _, err := client.RequestWithContext(ctx, &egoscale.UpdateReverseDNSForPublicIPAddress{
+			ID:         elasticIP.ID,
+			DomainName: reverseDNS,
+		}); err != nil {
+			return err
+		}
+	}
+
 	log.Printf("[DEBUG] %s: create finished successfully", resourceIPAddressIDString(d))
 
 	return resourceIPAddressRead(d, meta)
@@ -200,7 +278,10 @@ func resourceIPAddressExists(d *schema.ResourceData, meta interface{}) (bool, er
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return false, err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -226,7 +307,10 @@ func resourceIPAddressRead(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	ipAddress := &egoscale.IPAddress{
 		IsElastic: true,
@@ -247,10 +331,22 @@ func resourceIPAddressRead(d *schema.ResourceData, meta interface{}) error {
 	if err != nil {
 		return handleNotFound(d, err)
 	}
+	ipAddress = resp.(*egoscale.IPAddress)
+
+	// reverse_dns
+	resp, err = client.RequestWithContext(ctx, &egoscale.QueryReverseDNSForPublicIPAddress{
+		ID: ipAddress.ID,
+	})
+	if err != nil {
+		return err
+	}
+	if err := d.Set("reverse_dns", getReverseDNS(resp.(*egoscale.IPAddress).ReverseDNS)); err != nil {
+		return err
+	}
 
 	log.Printf("[DEBUG] %s: read finished successfully", resourceIPAddressIDString(d))
 
-	return resourceIPAddressApply(d, resp.(*egoscale.IPAddress))
+	return resourceIPAddressApply(d, ipAddress, meta)
 }
 
 func resourceIPAddressUpdate(d *schema.ResourceData, meta interface{}) error {
@@ -259,13 +355,16 @@ func resourceIPAddressUpdate(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutUpdate))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	d.Partial(true)
 
 	commands := make([]partialCommand, 0)
 
-	updateTags, err := updateTags(d, "tags", new(egoscale.IPAddress).ResourceType())
+	updateTags, err := updateTags(d, "tags", new(egoscale.IPAddress).ResourceType(), meta)
 	if err != nil {
 		return err
 	}
@@ -276,64 +375,65 @@ func resourceIPAddressUpdate(d *schema.ResourceData, meta interface{}) error {
 		})
 	}
 
-	eipPartials := make([]string, 0)
-	updateEIP := egoscale.UpdateIPAddress{}
-	if d.HasChange("healthcheck_port") {
-		eipPartials = append(eipPartials, "healthcheck_port")
-		updateEIP.HealthcheckPort = int64(d.Get("healthcheck_port").(int))
-		if _, ok := d.GetOk("healthcheck_mode"); ok && updateEIP.HealthcheckPort == 0 {
-			return errors.New("healthcheck_port must be specified")
+	if d.HasChange("reverse_dns") {
+		id, err := egoscale.ParseUUID(d.Id())
+		if err != nil {
+			return err
 		}
-	}
-	if d.HasChange("healthcheck_path") {
-		eipPartials = append(eipPartials, "healthcheck_path")
-		updateEIP.HealthcheckPath = d.Get("healthcheck_path").(string)
-		if healthcheckMode, ok := d.GetOk("healthcheck_mode"); ok {
-			if healthcheckMode == "http" && updateEIP.HealthcheckPath == "" {
-				return errors.New("healthcheck_path must be specified in \"http\" mode")
-			} else if healthcheckMode == "tcp" && updateEIP.HealthcheckPath != "" {
-				return errors.New("healthcheck_path must not be specified in \"tcp\" mode")
+
+		var request egoscale.Command = &egoscale.DeleteReverseDNSFromPublicIPAddress{ID: id}
+		if reverseDNS := d.Get("reverse_dns").(string); reverseDNS != "" {
+			request = &egoscale.UpdateReverseDNSForPublicIPAddress{
+				ID:         id,
+				DomainName: reverseDNS,
 			}
 		}
+
+		commands = append(commands, partialCommand{
+			partial: "reverse_dns",
+			request: request,
+		})
 	}
-	if d.HasChange("healthcheck_interval") {
-		eipPartials = append(eipPartials, "healthcheck_interval")
-		updateEIP.HealthcheckInterval = int64(d.Get("healthcheck_interval").(int))
-		if _, ok := d.GetOk("healthcheck_mode"); ok && updateEIP.HealthcheckInterval == 0 {
-			return errors.New("healthcheck_interval must be specified")
-		}
+
+	// The healthcheck is enabled, switched, updated or disabled at once
+	eipPartials := append([]string{"healthcheck_mode"}, resourceIPAddressHealthcheckKeys...)
+	updateEIP := false
+	for _, k := range eipPartials {
+		updateEIP = updateEIP || d.HasChange(k)
 	}
-	if d.HasChange("healthcheck_timeout") {
-		eipPartials = append(eipPartials, "healthcheck_timeout")
-		updateEIP.HealthcheckTimeout = int64(d.Get("healthcheck_timeout").(int))
-		if _, ok := d.GetOk("healthcheck_mode"); ok && updateEIP.HealthcheckTimeout == 0 {
-			return errors.New("healthcheck_timeout must be specified")
+	if updateEIP && d.Get("healthcheck_mode").(string) == "" {
+		id, err := egoscale.ParseUUID(d.Id())
+		if err != nil {
+			return err
 		}
-	}
-	if d.HasChange("healthcheck_strikes_ok") {
-		eipPartials = append(eipPartials, "healthcheck_strikes_ok")
-		updateEIP.HealthcheckStrikesOk = int64(d.Get("healthcheck_strikes_ok").(int))
-		if _, ok := d.GetOk("healthcheck_mode"); ok && updateEIP.HealthcheckStrikesOk == 0 {
-			return errors.New("healthcheck_strikes_ok must be specified")
+
+		// UpdateIPAddress omits the empty settings, the empty mode disabling
+		// the healthcheck is given explicitly
+		if _, err := client.RequestWithEmptyParamsContext(ctx, &egoscale.UpdateIPAddress{ID: id}, "mode"); err != nil {
+			return err
 		}
-	}
-	if d.HasChange("healthcheck_strikes_fail") {
-		eipPartials = append(eipPartials, "healthcheck_strikes_fail")
-		updateEIP.HealthcheckStrikesFail = int64(d.Get("healthcheck_strikes_fail").(int))
-		if _, ok := d.GetOk("healthcheck_mode"); ok && updateEIP.HealthcheckStrikesFail == 0 {
-			return errors.New("healthcheck_strikes_fail must be specified")
+
+		for _, partial := range eipPartials {
+			d.SetPartial(partial)
 		}
-	}
-	if len(eipPartials) > 0 {
+	} else if updateEIP {
 		id, err := egoscale.ParseUUID(d.Id())
 		if err != nil {
 			return err
 		}
 
-		updateEIP.ID = id
 		commands = append(commands, partialCommand{
 			partials: eipPartials,
-			request:  updateEIP,
+			request: &egoscale.UpdateIPAddress{
+				ID:                     id,
+				HealthcheckMode:        d.Get("healthcheck_mode").(string),
+				HealthcheckPort:        int64(d.Get("healthcheck_port").(int)),
+				HealthcheckPath:        d.Get("healthcheck_path").(string),
+				HealthcheckInterval:    int64(d.Get("healthcheck_interval").(int)),
+				HealthcheckTimeout:     int64(d.Get("healthcheck_timeout").(int)),
+				HealthcheckStrikesOk:   int64(d.Get("healthcheck_strikes_ok").(int)),
+				HealthcheckStrikesFail: int64(d.Get("healthcheck_strikes_fail").(int)),
+			},
 		})
 	}
 
@@ -364,7 +464,14 @@ func resourceIPAddressDelete(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutDelete))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	if err := checkDeletionProtection(d, "exoscale_ipaddress"); err != nil {
+		return err
+	}
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -382,7 +489,7 @@ func resourceIPAddressDelete(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
-func resourceIPAddressApply(d *schema.ResourceData, ip *egoscale.IPAddress) error {
+func resourceIPAddressApply(d *schema.ResourceData, ip *egoscale.IPAddress, meta interface{}) error {
 	d.SetId(ip.ID.String())
 	if err := d.Set("ip_address", ip.IPAddress.String()); err != nil {
 		return err
@@ -399,7 +506,12 @@ func resourceIPAddressApply(d *schema.ResourceData, ip *egoscale.IPAddress) erro
 		if err := d.Set("healthcheck_port", ip.Healthcheck.Port); err != nil {
 			return err
 		}
-		if err := d.Set("healthcheck_path", ip.Healthcheck.Path); err != nil {
+		// the path is kept when switching to "tcp" mode, where it is meaningless
+		path := ""
+		if ip.Healthcheck.Mode == "http" {
+			path = ip.Healthcheck.Path
+		}
+		if err := d.Set("healthcheck_path", path); err != nil {
 			return err
 		}
 		if err := d.Set("healthcheck_interval", ip.Healthcheck.Interval); err != nil {
@@ -417,11 +529,7 @@ func resourceIPAddressApply(d *schema.ResourceData, ip *egoscale.IPAddress) erro
 	}
 
 	// tags
-	tags := make(map[string]interface{})
-	for _, tag := range ip.Tags {
-		tags[tag.Key] = tag.Value
-	}
-	if err := d.Set("tags", tags); err != nil {
+	if err := setTags(d, "tags", ip.Tags, meta); err != nil {
 		return err
 	}
 
@@ -0,0 +1,354 @@
+package exoscale
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+
+	"github.com/exoscale/egoscale"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceIPAddressAssociationIDString(d resourceIDStringer) string {
+	return resourceIDString(d, "exoscale_ipaddress_association")
+}
+
+func resourceIPAddressAssociation() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"ip_address": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  "Elastic IP address",
+				ValidateFunc: ValidateIPv4String,
+			},
+			"compute_ids": {
+				Type:        schema.TypeSet,
+				Required:    true,
+				Set:         schema.HashString,
+				Description: "IDs of the Compute instances holding the Elastic IP address on their public network NIC",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: ValidateUUID(),
+				},
+			},
+			"zone": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+
+		Create: resourceIPAddressAssociationCreate,
+		Read:   resourceIPAddressAssociationRead,
+		Update: resourceIPAddressAssociationUpdate,
+		Delete: resourceIPAddressAssociationDelete,
+		Exists: resourceIPAddressAssociationExists,
+
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Update: schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
+		},
+	}
+}
+
+func resourceIPAddressAssociationCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: beginning create", resourceIPAddressAssociationIDString(d))
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.GetWithContext(ctx, &egoscale.IPAddress{
+		IPAddress: net.ParseIP(d.Get("ip_address").(string)),
+		IsElastic: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	elasticIP := resp.(*egoscale.IPAddress)
+
+	d.SetId(elasticIP.ID.String())
+
+	if err := syncIPAddressAssociation(ctx, client, elasticIP, d.Get("compute_ids").(*schema.Set)); err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] %s: create finished successfully", resourceIPAddressAssociationIDString(d))
+
+	return resourceIPAddressAssociationRead(d, meta)
+}
+
+func resourceIPAddressAssociationExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = getIPAddressAssociation(ctx, client, d.Id())
+	if err != nil {
+		e := handleNotFound(d, err)
+		return d.Id() != "", e
+	}
+
+	return true, nil
+}
+
+func resourceIPAddressAssociationRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: beginning read", resourceIPAddressAssociationIDString(d))
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
+
+	elasticIP, err := getIPAddressAssociation(ctx, client, d.Id())
+	if err != nil {
+		return handleNotFound(d, err)
+	}
+
+	members, err := getIPAddressAssociationMembers(ctx, client, elasticIP)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] %s: read finished successfully", resourceIPAddressAssociationIDString(d))
+
+	return resourceIPAddressAssociationApply(d, elasticIP, members)
+}
+
+func resourceIPAddressAssociationUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: beginning update", resourceIPAddressAssociationIDString(d))
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
+
+	elasticIP, err := getIPAddressAssociation(ctx, client, d.Id())
+	if err != nil {
+		return err
+	}
+
+	if err := syncIPAddressAssociation(ctx, client, elasticIP, d.Get("compute_ids").(*schema.Set)); err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] %s: update finished successfully", resourceIPAddressAssociationIDString(d))
+
+	return resourceIPAddressAssociationRead(d, meta)
+}
+
+func resourceIPAddressAssociationDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: beginning delete", resourceIPAddressAssociationIDString(d))
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutDelete))
+	defer cancel()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
+
+	elasticIP, err := getIPAddressAssociation(ctx, client, d.Id())
+	if err != nil {
+		// the elastic IP address is already gone
+		if e := handleNotFound(d, err); e == nil {
+			return nil
+		}
+		return err
+	}
+
+	if err := syncIPAddressAssociation(ctx, client, elasticIP, schema.NewSet(schema.HashString, nil)); err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] %s: delete finished successfully", resourceIPAddressAssociationIDString(d))
+
+	return nil
+}
+
+func resourceIPAddressAssociationApply(d *schema.ResourceData, elasticIP *egoscale.IPAddress, members map[string]egoscale.NicSecondaryIP) error {
+	d.SetId(elasticIP.ID.String())
+
+	if err := d.Set("ip_address", elasticIP.IPAddress.String()); err != nil {
+		return err
+	}
+	if err := d.Set("zone", elasticIP.ZoneName); err != nil {
+		return err
+	}
+
+	// Report the members added or removed out of band, the next plan puts
+	// them back in line with the configuration. There are none yet on import.
+	if computeIDs := d.Get("compute_ids").(*schema.Set); computeIDs.Len() > 0 {
+		for _, id := range sortedIPAddressAssociationMembers(members) {
+			if !computeIDs.Contains(id) {
+				log.Printf("[WARN] %s: %s holds the elastic IP address but is not a member", resourceIPAddressAssociationIDString(d), id)
+			}
+		}
+		for _, id := range computeIDs.List() {
+			if _, ok := members[id.(string)]; !ok {
+				log.Printf("[WARN] %s: %s is a member but doesn't hold the elastic IP address", resourceIPAddressAssociationIDString(d), id)
+			}
+		}
+	}
+
+	ids := make([]interface{}, 0, len(members))
+	for id := range members {
+		ids = append(ids, id)
+	}
+
+	return d.Set("compute_ids", schema.NewSet(schema.HashString, ids))
+}
+
+// getIPAddressAssociation fetches the elastic IP address by ID or, when
+// importing, by IP address
+func getIPAddressAssociation(ctx context.Context, client *computeClient, id string) (*egoscale.IPAddress, error) {
+	elasticIP := &egoscale.IPAddress{
+		IsElastic: true,
+	}
+
+	uuid, err := egoscale.ParseUUID(id)
+	if err != nil {
+		ip := net.ParseIP(id)
+		if ip == nil {
+			return nil, fmt.Errorf("%q is neither a valid ID or IP address", id)
+		}
+		elasticIP.IPAddress = ip
+	} else {
+		elasticIP.ID = uuid
+	}
+
+	resp, err := client.GetWithContext(ctx, elasticIP)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*egoscale.IPAddress), nil
+}
+
+// getIPAddressAssociationMembers returns the secondary IPs of the elastic IP
+// address, by Compute instance ID, found on the public NIC of the Compute
+// instances of its zone
+func getIPAddressAssociationMembers(ctx context.Context, client *computeClient, elasticIP *egoscale.IPAddress) (map[string]egoscale.NicSecondaryIP, error) {
+	members := make(map[string]egoscale.NicSecondaryIP)
+
+	var err error
+	client.PaginateWithContext(ctx, &egoscale.ListVirtualMachines{ZoneID: elasticIP.ZoneID}, func(v interface{}, e error) bool {
+		if e != nil {
+			err = e
+			return false
+		}
+
+		vm := v.(*egoscale.VirtualMachine)
+		nic := getComputePublicNic(vm)
+		if nic == nil {
+			return true
+		}
+
+		for _, ip := range nic.SecondaryIP {
+			if ip.IPAddress.Equal(elasticIP.IPAddress) {
+				members[vm.ID.String()] = ip
+				break
+			}
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// syncIPAddressAssociation removes the elastic IP address from the public NIC
+// of the Compute instances which aren't wanted and adds it to the missing ones
+func syncIPAddressAssociation(ctx context.Context, client *computeClient, elasticIP *egoscale.IPAddress, computeIDs *schema.Set) error {
+	members, err := getIPAddressAssociationMembers(ctx, client, elasticIP)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range sortedIPAddressAssociationMembers(members) {
+		if computeIDs.Contains(id) {
+			continue
+		}
+
+		if err := client.BooleanRequestWithContext(ctx, &egoscale.RemoveIPFromNic{ID: members[id].ID}); err != nil {
+			return fmt.Errorf("unable to remove the elastic IP address from %s: %s", id, err)
+		}
+	}
+
+	wanted := make([]string, 0, computeIDs.Len())
+	for _, id := range computeIDs.List() {
+		wanted = append(wanted, id.(string))
+	}
+	sort.Strings(wanted)
+
+	for _, id := range wanted {
+		if _, ok := members[id]; ok {
+			continue
+		}
+
+		virtualMachineID, err := egoscale.ParseUUID(id)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.RequestWithContext(ctx, &egoscale.ListNics{
+			VirtualMachineID: virtualMachineID,
+		})
+		if err != nil {
+			return err
+		}
+
+		// The default NIC may be a private one
+		nic := getComputePublicNic(&egoscale.VirtualMachine{Nic: resp.(*egoscale.ListNicsResponse).Nic})
+		if nic == nil {
+			return fmt.Errorf("No public NIC found for %v", virtualMachineID)
+		}
+
+		if _, err := client.RequestWithContext(ctx, &egoscale.AddIPToNic{
+			NicID:     nic.ID,
+			IPAddress: elasticIP.IPAddress,
+		}); err != nil {
+			return fmt.Errorf("unable to add the elastic IP address to %s: %s", id, err)
+		}
+	}
+
+	return nil
+}
+
+func sortedIPAddressAssociationMembers(members map[string]egoscale.NicSecondaryIP) []string {
+	ids := make([]string, 0, len(members))
+	for id := range members {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
@@ -0,0 +1,300 @@
+package exoscale
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/exoscale/egoscale"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccResourceIPAddressAssociation(t *testing.T) {
+	vm1 := new(egoscale.VirtualMachine)
+	vm2 := new(egoscale.VirtualMachine)
+	eip := new(egoscale.IPAddress)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceIPAddressAssociationDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceIPAddressAssociationConfig, `"${exoscale_compute.vm1.id}", "${exoscale_compute.vm2.id}"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm1", vm1),
+					testAccCheckResourceComputeExists("exoscale_compute.vm2", vm2),
+					testAccCheckIPAddressExists("exoscale_ipaddress.eip", eip),
+					testAccCheckResourceIPAddressAssociation(eip, vm1, vm2),
+					testAccCheckResourceIPAddressAssociationAttributes(testAttrs{
+						"compute_ids.#": ValidateString("2"),
+						"zone":          ValidateString(defaultExoscaleZone),
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceIPAddressAssociationConfig, `"${exoscale_compute.vm2.id}"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceIPAddressAssociation(eip, vm2),
+					testAccCheckResourceIPAddressAssociationAttributes(testAttrs{
+						"compute_ids.#": ValidateString("1"),
+					}),
+				),
+			},
+			{
+				// The membership changed outside of Terraform is restored
+				PreConfig: func() {
+					client, err := GetComputeClient(testAccProvider.Meta())
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if _, err := client.Request(&egoscale.AddIPToNic{
+						NicID:     getComputePublicNic(vm1).ID,
+						IPAddress: eip.IPAddress,
+					}); err != nil {
+						t.Fatal(err)
+					}
+
+					resp, err := client.Get(&egoscale.VirtualMachine{ID: vm2.ID})
+					if err != nil {
+						t.Fatal(err)
+					}
+					for _, ip := range getComputePublicNic(resp.(*egoscale.VirtualMachine)).SecondaryIP {
+						if !ip.IPAddress.Equal(eip.IPAddress) {
+							continue
+						}
+						if err := client.BooleanRequest(&egoscale.RemoveIPFromNic{ID: ip.ID}); err != nil {
+							t.Fatal(err)
+						}
+					}
+				},
+				Config: fmt.Sprintf(testAccResourceIPAddressAssociationConfig, `"${exoscale_compute.vm2.id}"`),
+				Check:  testAccCheckResourceIPAddressAssociation(eip, vm2),
+			},
+			{
+				ResourceName:      "exoscale_ipaddress_association.vip",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateCheck: func(s []*terraform.InstanceState) error {
+					return checkResourceAttributes(
+						testAttrs{
+							"compute_ids.#": ValidateString("1"),
+							"zone":          ValidateString(defaultExoscaleZone),
+						},
+						s[0].Attributes)
+				},
+			},
+		},
+	})
+}
+
+func TestAccResourceIPAddressAssociationDefaultNic(t *testing.T) {
+	vm := new(egoscale.VirtualMachine)
+	eip := new(egoscale.IPAddress)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceIPAddressAssociationDestroy,
+		Steps: []resource.TestStep{
+			{
+				// The elastic IP address goes to the public NIC, not the default one
+				Config: testAccResourceIPAddressAssociationConfigDefaultNic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceComputeExists("exoscale_compute.vm", vm),
+					testAccCheckIPAddressExists("exoscale_ipaddress.eip", eip),
+					testAccCheckResourceComputeDefaultNic(vm, "terraform-test-managed"),
+					testAccCheckResourceIPAddressAssociation(eip, vm),
+					testAccCheckResourceIPAddressAssociationAttributes(testAttrs{
+						"compute_ids.#": ValidateString("1"),
+					}),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckResourceIPAddressAssociation checks that the elastic IP address
+// is held by the public NIC of the members and of them only
+func testAccCheckResourceIPAddressAssociation(eip *egoscale.IPAddress, members ...*egoscale.VirtualMachine) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client, err := GetComputeClient(testAccProvider.Meta())
+		if err != nil {
+			return err
+		}
+
+		vms, err := client.List(&egoscale.VirtualMachine{ZoneID: eip.ZoneID})
+		if err != nil {
+			return err
+		}
+
+		for _, v := range vms {
+			vm := v.(*egoscale.VirtualMachine)
+
+			expected := false
+			for _, member := range members {
+				expected = expected || member.ID.Equal(*vm.ID)
+			}
+
+			found := false
+			if nic := getComputePublicNic(vm); nic != nil {
+				for _, ip := range nic.SecondaryIP {
+					found = found || ip.IPAddress.Equal(eip.IPAddress)
+				}
+			}
+
+			if found != expected {
+				return fmt.Errorf("Compute instance %s: expected the elastic IP address to be held: %t, got %t", vm.ID, expected, found)
+			}
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckResourceIPAddressAssociationAttributes(expected testAttrs) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "exoscale_ipaddress_association" {
+				continue
+			}
+
+			return checkResourceAttributes(expected, rs.Primary.Attributes)
+		}
+
+		return errors.New("resource not found in the state")
+	}
+}
+
+func testAccCheckResourceIPAddressAssociationDestroy(s *terraform.State) error {
+	client, err := GetComputeClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "exoscale_ipaddress_association" {
+			continue
+		}
+
+		vms, err := client.List(&egoscale.VirtualMachine{})
+		if err != nil {
+			return err
+		}
+
+		for _, v := range vms {
+			nic := v.(*egoscale.VirtualMachine).DefaultNic()
+			if nic == nil {
+				continue
+			}
+
+			for _, ip := range nic.SecondaryIP {
+				if ip.IPAddress.String() == rs.Primary.Attributes["ip_address"] {
+					return errors.New("Elastic IP address still associated")
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+var testAccResourceIPAddressAssociationConfig = fmt.Sprintf(`
+resource "exoscale_ssh_keypair" "key" {
+  name = "terraform-test-keypair"
+}
+
+resource "exoscale_ipaddress" "eip" {
+  zone = %q
+
+  tags = {
+    test = "acceptance"
+  }
+}
+
+resource "exoscale_compute" "vm1" {
+  display_name = "terraform-test-compute1"
+  template = %q
+  zone = %q
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.key.name}"
+
+  # prevents bad ordering during the deletion
+  depends_on = ["exoscale_ipaddress.eip"]
+}
+
+resource "exoscale_compute" "vm2" {
+  display_name = "terraform-test-compute2"
+  template = %q
+  zone = %q
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.key.name}"
+
+  # prevents bad ordering during the deletion
+  depends_on = ["exoscale_ipaddress.eip"]
+}
+
+resource "exoscale_ipaddress_association" "vip" {
+  ip_address = "${exoscale_ipaddress.eip.ip_address}"
+  compute_ids = [%%s]
+}
+`,
+	defaultExoscaleZone,
+	defaultExoscaleTemplate,
+	defaultExoscaleZone,
+	defaultExoscaleTemplate,
+	defaultExoscaleZone,
+)
+
+var testAccResourceIPAddressAssociationConfigDefaultNic = fmt.Sprintf(`
+resource "exoscale_ssh_keypair" "key" {
+  name = "terraform-test-keypair"
+}
+
+resource "exoscale_ipaddress" "eip" {
+  zone = %q
+}
+
+resource "exoscale_network" "managed" {
+  zone = %q
+  network_offering = %q
+  name = "terraform-test-managed"
+
+  start_ip = "10.0.0.1"
+  end_ip = "10.0.0.5"
+  netmask = "255.255.255.0"
+}
+
+resource "exoscale_compute" "vm" {
+  display_name = "terraform-test-compute"
+  template = %q
+  zone = %q
+  size = "Micro"
+  disk_size = "12"
+  key_pair = "${exoscale_ssh_keypair.key.name}"
+
+  default_nic_network_id = "${exoscale_network.managed.id}"
+
+  network_interface {
+    network_id = "${exoscale_network.managed.id}"
+  }
+
+  # prevents bad ordering during the deletion
+  depends_on = ["exoscale_ipaddress.eip"]
+}
+
+resource "exoscale_ipaddress_association" "vip" {
+  ip_address = "${exoscale_ipaddress.eip.ip_address}"
+  compute_ids = ["${exoscale_compute.vm.id}"]
+}
+`,
+	defaultExoscaleZone,
+	defaultExoscaleZone,
+	defaultExoscaleNetworkOffering,
+	defaultExoscaleTemplate,
+	defaultExoscaleZone,
+)
@@ -1,8 +1,12 @@
 package exoscale
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/exoscale/egoscale"
@@ -25,8 +29,63 @@ const (
 	testIPHealthcheckTimeout2     int64 = 2
 	testIPHealthcheckStrikesOk2   int64 = 2
 	testIPHealthcheckStrikesFail2 int64 = 3
+	testIPReverseDNS1                   = "terraform-test-eip1.example.net"
+	testIPReverseDNS2                   = "terraform-test-eip2.example.net"
 )
 
+func init() {
+	resource.AddTestSweepers("exoscale_ipaddress", &resource.Sweeper{
+		Name:         "exoscale_ipaddress",
+		Dependencies: []string{"exoscale_compute"},
+		F:            testSweepIPAddress,
+	})
+}
+
+// testSweepIPAddress releases the leaked Elastic IPs, having no name they are
+// recognized by the test = "acceptance" tag set by the test configurations.
+func testSweepIPAddress(zoneName string) error {
+	config, err := testSweeperConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := GetComputeClient(config)
+	if err != nil {
+		return err
+	}
+
+	zone, err := getZoneByName(ctx, client, zoneName)
+	if err != nil {
+		return err
+	}
+
+	ips, err := client.ListWithContext(ctx, &egoscale.IPAddress{ZoneID: zone.ID, IsElastic: true})
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, item := range ips {
+		ip := item.(*egoscale.IPAddress)
+
+		tagged := false
+		for _, tag := range ip.Tags {
+			tagged = tagged || (tag.Key == "test" && tag.Value == "acceptance")
+		}
+		if !tagged {
+			continue
+		}
+
+		log.Printf("[INFO] sweeping exoscale_ipaddress %s (ID = %s)", ip.IPAddress, ip.ID)
+		if err := client.DeleteWithContext(ctx, ip); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to release IP address %s: %s", ip.ID, err))
+		}
+	}
+
+	return testSweepErrors(errs)
+}
+
 func TestAccResourceIPAddress(t *testing.T) {
 	eip := new(egoscale.IPAddress)
 
@@ -48,6 +107,7 @@ func TestAccResourceIPAddress(t *testing.T) {
 						"healthcheck_timeout":      ValidateString(fmt.Sprint(testIPHealthcheckTimeout1)),
 						"healthcheck_strikes_ok":   ValidateString(fmt.Sprint(testIPHealthcheckStrikesOk1)),
 						"healthcheck_strikes_fail": ValidateString(fmt.Sprint(testIPHealthcheckStrikesFail1)),
+						"reverse_dns":              ValidateString(testIPReverseDNS1),
 					}),
 				),
 			},
@@ -64,6 +124,7 @@ func TestAccResourceIPAddress(t *testing.T) {
 						"healthcheck_timeout":      ValidateString(fmt.Sprint(testIPHealthcheckTimeout2)),
 						"healthcheck_strikes_ok":   ValidateString(fmt.Sprint(testIPHealthcheckStrikesOk2)),
 						"healthcheck_strikes_fail": ValidateString(fmt.Sprint(testIPHealthcheckStrikesFail2)),
+						"reverse_dns":              ValidateString(testIPReverseDNS2),
 					}),
 				),
 			},
@@ -81,6 +142,269 @@ func TestAccResourceIPAddress(t *testing.T) {
 							"healthcheck_timeout":      ValidateString(fmt.Sprint(testIPHealthcheckTimeout2)),
 							"healthcheck_strikes_ok":   ValidateString(fmt.Sprint(testIPHealthcheckStrikesOk2)),
 							"healthcheck_strikes_fail": ValidateString(fmt.Sprint(testIPHealthcheckStrikesFail2)),
+							"reverse_dns":              ValidateString(testIPReverseDNS2),
+						},
+						s[0].Attributes)
+				},
+			},
+		},
+	})
+}
+
+func TestAccResourceIPAddressReverseDNS(t *testing.T) {
+	eip := new(egoscale.IPAddress)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckIPAddressDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, `reverse_dns = "localhost"`),
+				ExpectError: regexp.MustCompile("fully qualified domain name"),
+			},
+			{
+				Config: fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, fmt.Sprintf("reverse_dns = %q", testIPReverseDNS1)),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckIPAddressExists("exoscale_ipaddress.eip", eip),
+					testAccCheckIPAddressReverseDNS(eip, testIPReverseDNS1+"."),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckIPAddressAttributes(testAttrs{
+						"reverse_dns": ValidateString(""),
+					}),
+					testAccCheckIPAddressReverseDNS(eip, ""),
+				),
+			},
+		},
+	})
+}
+
+func TestAccResourceIPAddressDeletionProtection(t *testing.T) {
+	eip := new(egoscale.IPAddress)
+	updated := new(egoscale.IPAddress)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckIPAddressDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, "deletion_protection = true"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckIPAddressExists("exoscale_ipaddress.eip", eip),
+					resource.TestCheckResourceAttr("exoscale_ipaddress.eip", "deletion_protection", "true"),
+				),
+			},
+			{
+				// The protection must be disabled by a separate apply
+				Config:      fmt.Sprintf(testAccIPAddressConfig, testAccZone2, "deletion_protection = false"),
+				ExpectError: regexp.MustCompile(`deletion protection is enabled, changing "zone" would replace the resource`),
+			},
+			{
+				Config:      fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, "deletion_protection = true"),
+				Destroy:     true,
+				ExpectError: regexp.MustCompile("deletion protection is enabled"),
+			},
+			{
+				Config: fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckIPAddressExists("exoscale_ipaddress.eip", updated),
+					resource.TestCheckResourceAttr("exoscale_ipaddress.eip", "deletion_protection", "false"),
+					func(s *terraform.State) error {
+						if !updated.ID.Equal(*eip.ID) {
+							return errors.New("expected the IP address to be updated in place")
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccResourceIPAddressHealthcheckMode(t *testing.T) {
+	eip := new(egoscale.IPAddress)
+	updated := new(egoscale.IPAddress)
+
+	tcp := `
+  healthcheck_mode = "tcp"
+  healthcheck_port = 22
+  healthcheck_interval = 10
+  healthcheck_timeout = 5
+  healthcheck_strikes_ok = 2
+  healthcheck_strikes_fail = 3
+`
+	http := `
+  healthcheck_mode = "http"
+  healthcheck_port = 80
+  healthcheck_path = "/health"
+  healthcheck_interval = 10
+  healthcheck_timeout = 5
+  healthcheck_strikes_ok = 2
+  healthcheck_strikes_fail = 3
+`
+
+	// testAccCheckIPAddressInPlace checks that the IP address is still the
+	// one created by the first step
+	testAccCheckIPAddressInPlace := func(s *terraform.State) error {
+		if !updated.ID.Equal(*eip.ID) || !updated.IPAddress.Equal(eip.IPAddress) {
+			return errors.New("expected the IP address to be updated in place")
+		}
+		return nil
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckIPAddressDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, "healthcheck_port = 22"),
+				ExpectError: regexp.MustCompile(`"healthcheck_port" can only be specified with healthcheck_mode`),
+			},
+			{
+				Config: fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckIPAddressExists("exoscale_ipaddress.eip", eip),
+					resource.TestCheckNoResourceAttr("exoscale_ipaddress.eip", "healthcheck_mode"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, tcp),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckIPAddressExists("exoscale_ipaddress.eip", updated),
+					testAccCheckIPAddressInPlace,
+					testAccCheckIPAddressAttributes(testAttrs{
+						"healthcheck_mode": ValidateString("tcp"),
+						"healthcheck_port": ValidateString("22"),
+					}),
+				),
+			},
+			{
+				Config:      fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, strings.Replace(tcp, `"tcp"`, `"http"`, 1)),
+				ExpectError: regexp.MustCompile(`healthcheck_path must be specified in "http" mode`),
+			},
+			{
+				Config: fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, http),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckIPAddressExists("exoscale_ipaddress.eip", updated),
+					testAccCheckIPAddressInPlace,
+					testAccCheckIPAddressAttributes(testAttrs{
+						"healthcheck_mode": ValidateString("http"),
+						"healthcheck_port": ValidateString("80"),
+						"healthcheck_path": ValidateString("/health"),
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, tcp),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckIPAddressExists("exoscale_ipaddress.eip", updated),
+					testAccCheckIPAddressInPlace,
+					testAccCheckIPAddressAttributes(testAttrs{
+						"healthcheck_mode": ValidateString("tcp"),
+						"healthcheck_path": ValidateString(""),
+					}),
+				),
+			},
+			{
+				// The healthcheck is disabled in place
+				Config: fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckIPAddressExists("exoscale_ipaddress.eip", updated),
+					testAccCheckIPAddressInPlace,
+					func(s *terraform.State) error {
+						if updated.Healthcheck != nil {
+							return fmt.Errorf("expected the healthcheck to be disabled, got %#v", updated.Healthcheck)
+						}
+						return nil
+					},
+					resource.TestCheckResourceAttr("exoscale_ipaddress.eip", "healthcheck_mode", ""),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccIPAddressConfig, defaultExoscaleZone, tcp),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckIPAddressExists("exoscale_ipaddress.eip", updated),
+					testAccCheckIPAddressInPlace,
+					testAccCheckIPAddressAttributes(testAttrs{
+						"healthcheck_mode": ValidateString("tcp"),
+					}),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckIPAddressReverseDNS(eip *egoscale.IPAddress, expected string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client, err := GetComputeClient(testAccProvider.Meta())
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Request(&egoscale.QueryReverseDNSForPublicIPAddress{ID: eip.ID})
+		if err != nil {
+			return err
+		}
+
+		domainName := ""
+		if records := resp.(*egoscale.IPAddress).ReverseDNS; len(records) > 0 {
+			domainName = records[0].DomainName
+		}
+		if domainName != expected {
+			return fmt.Errorf("expected the PTR record %q, got %q", expected, domainName)
+		}
+
+		return nil
+	}
+}
+
+func TestAccResourceIPAddressProviderZone(t *testing.T) {
+	eip := new(egoscale.IPAddress)
+	replaced := new(egoscale.IPAddress)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckIPAddressDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccIPAddressConfigProviderZone, defaultExoscaleZone),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckIPAddressExists("exoscale_ipaddress.eip", eip),
+					testAccCheckIPAddressAttributes(testAttrs{
+						"zone": ValidateString(defaultExoscaleZone),
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccIPAddressConfigProviderZone, testAccZone2),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckIPAddressExists("exoscale_ipaddress.eip", replaced),
+					func(s *terraform.State) error {
+						if replaced.ID.Equal(*eip.ID) {
+							return errors.New("expected the IP address to be replaced")
+						}
+						return nil
+					},
+					testAccCheckIPAddressAttributes(testAttrs{
+						"zone": ValidateString(testAccZone2),
+					}),
+				),
+			},
+			{
+				ResourceName:      "exoscale_ipaddress.eip",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateCheck: func(s []*terraform.InstanceState) error {
+					return checkResourceAttributes(
+						testAttrs{
+							"zone": ValidateString(testAccZone2),
 						},
 						s[0].Attributes)
 				},
@@ -105,7 +429,10 @@ func testAccCheckIPAddressExists(n string, eip *egoscale.IPAddress) resource.Tes
 			return err
 		}
 
-		client := GetComputeClient(testAccProvider.Meta())
+		client, err := GetComputeClient(testAccProvider.Meta())
+		if err != nil {
+			return err
+		}
 		eip.ID = id
 		resp, err := client.Get(eip)
 		if err != nil {
@@ -229,7 +556,10 @@ func testAccCheckIPAddressAttributes(expected testAttrs) resource.TestCheckFunc
 }
 
 func testAccCheckIPAddressDestroy(s *terraform.State) error {
-	client := GetComputeClient(testAccProvider.Meta())
+	client, err := GetComputeClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "exoscale_ipaddress" {
@@ -269,6 +599,7 @@ resource "exoscale_ipaddress" "eip" {
   healthcheck_timeout = %d
   healthcheck_strikes_ok = %d
   healthcheck_strikes_fail = %d
+  reverse_dns = "%s"
   tags = {
     test = "acceptance"
   }
@@ -282,6 +613,7 @@ resource "exoscale_ipaddress" "eip" {
 	testIPHealthcheckTimeout1,
 	testIPHealthcheckStrikesOk1,
 	testIPHealthcheckStrikesFail1,
+	testIPReverseDNS1,
 )
 
 var testAccIPAddressConfigUpdate = fmt.Sprintf(`
@@ -294,6 +626,7 @@ resource "exoscale_ipaddress" "eip" {
   healthcheck_timeout = %d
   healthcheck_strikes_ok = %d
   healthcheck_strikes_fail = %d
+  reverse_dns = "%s."
 }
 `,
 	defaultExoscaleZone,
@@ -304,4 +637,27 @@ resource "exoscale_ipaddress" "eip" {
 	testIPHealthcheckTimeout2,
 	testIPHealthcheckStrikesOk2,
 	testIPHealthcheckStrikesFail2,
+	testIPReverseDNS2,
 )
+
+var testAccIPAddressConfigProviderZone = `
+provider "exoscale" {
+  zone = "%s"
+}
+
+resource "exoscale_ipaddress" "eip" {
+  tags = {
+    test = "acceptance"
+  }
+}
+`
+
+var testAccIPAddressConfig = `
+resource "exoscale_ipaddress" "eip" {
+  zone = %q
+  %s
+  tags = {
+    test = "acceptance"
+  }
+}
+`
@@ -19,9 +19,10 @@ func resourceNetworkIDString(d resourceIDStringer) string {
 func resourceNetwork() *schema.Resource {
 	s := map[string]*schema.Schema{
 		"zone": {
-			Type:     schema.TypeString,
-			Required: true,
-			ForceNew: true,
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "Name of the zone (by default: the provider zone)",
 		},
 		"network_offering": {
 			Type:     schema.TypeString,
@@ -54,6 +55,7 @@ func resourceNetwork() *schema.Resource {
 	}
 
 	addTags(s, "tags")
+	addDeletionProtection(s)
 
 	return &schema.Resource{
 		Schema: s,
@@ -64,8 +66,10 @@ func resourceNetwork() *schema.Resource {
 		Delete: resourceNetworkDelete,
 		Exists: resourceNetworkExists,
 
+		CustomizeDiff: resourceNetworkCustomizeDiff(customizeDiffDeletionProtection(s)),
+
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			State: importStateDeletionProtection,
 		},
 
 		Timeouts: &schema.ResourceTimeout{
@@ -77,13 +81,28 @@ func resourceNetwork() *schema.Resource {
 	}
 }
 
+// resourceNetworkCustomizeDiff is built along with the schema, so is the given
+// deletion protection check
+func resourceNetworkCustomizeDiff(deletionProtection schema.CustomizeDiffFunc) schema.CustomizeDiffFunc {
+	return func(d *schema.ResourceDiff, meta interface{}) error {
+		if err := customizeDiffTags("tags")(d, meta); err != nil {
+			return err
+		}
+
+		return deletionProtection(d, meta)
+	}
+}
+
 func resourceNetworkCreate(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[DEBUG] %s: beginning create", resourceNetworkIDString(d))
 
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	name := d.Get("name").(string)
 	displayText := d.Get("display_text").(string)
@@ -131,7 +150,7 @@ func resourceNetworkCreate(d *schema.ResourceData, meta interface{}) error {
 	network := resp.(*egoscale.Network)
 	d.SetId(network.ID.String())
 
-	cmd, err := createTags(d, "tags", network.ResourceType())
+	cmd, err := createTags(d, "tags", network.ResourceType(), meta)
 	if err != nil {
 		return err
 	}
@@ -161,7 +180,10 @@ func resourceNetworkRead(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -185,14 +207,17 @@ func resourceNetworkRead(d *schema.ResourceData, meta interface{}) error {
 
 	log.Printf("[DEBUG] %s: read finished successfully", resourceNetworkIDString(d))
 
-	return resourceNetworkApply(d, &network)
+	return resourceNetworkApply(d, &network, meta)
 }
 
 func resourceNetworkExists(d *schema.ResourceData, meta interface{}) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return false, err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -223,7 +248,10 @@ func resourceNetworkUpdate(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutUpdate))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -250,7 +278,7 @@ func resourceNetworkUpdate(d *schema.ResourceData, meta interface{}) error {
 	}
 
 	// Update tags
-	requests, err := updateTags(d, "tags", egoscale.Network{}.ResourceType())
+	requests, err := updateTags(d, "tags", egoscale.Network{}.ResourceType(), meta)
 	if err != nil {
 		return err
 	}
@@ -275,7 +303,14 @@ func resourceNetworkDelete(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutDelete))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	if err := checkDeletionProtection(d, "exoscale_network"); err != nil {
+		return err
+	}
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -293,7 +328,7 @@ func resourceNetworkDelete(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
-func resourceNetworkApply(d *schema.ResourceData, network *egoscale.Network) error {
+func resourceNetworkApply(d *schema.ResourceData, network *egoscale.Network, meta interface{}) error {
 	d.SetId(network.ID.String())
 	if err := d.Set("name", network.Name); err != nil {
 		return err
@@ -328,11 +363,7 @@ func resourceNetworkApply(d *schema.ResourceData, network *egoscale.Network) err
 	}
 
 	// tags
-	tags := make(map[string]interface{})
-	for _, tag := range network.Tags {
-		tags[tag.Key] = tag.Value
-	}
-	if err := d.Set("tags", tags); err != nil {
+	if err := setTags(d, "tags", network.Tags, meta); err != nil {
 		return err
 	}
 
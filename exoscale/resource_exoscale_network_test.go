@@ -1,9 +1,13 @@
 package exoscale
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"net"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/exoscale/egoscale"
@@ -11,6 +15,52 @@ import (
 	"github.com/hashicorp/terraform/terraform"
 )
 
+func init() {
+	resource.AddTestSweepers("exoscale_network", &resource.Sweeper{
+		Name:         "exoscale_network",
+		Dependencies: []string{"exoscale_compute"},
+		F:            testSweepNetwork,
+	})
+}
+
+func testSweepNetwork(zoneName string) error {
+	config, err := testSweeperConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := GetComputeClient(config)
+	if err != nil {
+		return err
+	}
+
+	zone, err := getZoneByName(ctx, client, zoneName)
+	if err != nil {
+		return err
+	}
+
+	networks, err := client.ListWithContext(ctx, &egoscale.Network{ZoneID: zone.ID})
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, item := range networks {
+		network := item.(*egoscale.Network)
+		if !strings.HasPrefix(network.Name, testPrefix) {
+			continue
+		}
+
+		log.Printf("[INFO] sweeping exoscale_network %s (ID = %s)", network.Name, network.ID)
+		if err := client.BooleanRequestWithContext(ctx, &egoscale.DeleteNetwork{ID: network.ID}); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to delete network %s: %s", network.ID, err))
+		}
+	}
+
+	return testSweepErrors(errs)
+}
+
 func TestAccResourceNetwork(t *testing.T) {
 	network := new(egoscale.Network)
 
@@ -62,6 +112,121 @@ func TestAccResourceNetwork(t *testing.T) {
 	})
 }
 
+func TestAccResourceNetworkDefaultTags(t *testing.T) {
+	network := new(egoscale.Network)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceNetworkDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceNetworkConfigDefaultTags, "infra"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceNetworkExists("exoscale_network.net", network),
+					testAccCheckResourceNetworkAttributes(testAttrs{
+						"tags.%":             ValidateString("2"),
+						"tags.managedby":     ValidateString("terraform"),
+						"tags.env":           ValidateString("prod"),
+						"tags_all.%":         ValidateString("3"),
+						"tags_all.managedby": ValidateString("terraform"),
+						"tags_all.env":       ValidateString("prod"),
+						"tags_all.team":      ValidateString("infra"),
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceNetworkConfigDefaultTags, "platform"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceNetworkExists("exoscale_network.net", network),
+					testAccCheckResourceNetworkAttributes(testAttrs{
+						"tags.%":        ValidateString("2"),
+						"tags_all.%":    ValidateString("3"),
+						"tags_all.env":  ValidateString("prod"),
+						"tags_all.team": ValidateString("platform"),
+					}),
+				),
+			},
+		},
+	})
+}
+
+func TestAccResourceNetworkInterpolatedTags(t *testing.T) {
+	network := new(egoscale.Network)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceNetworkDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceNetworkConfigInterpolatedTags, "terraform-test-keypair1"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceNetworkExists("exoscale_network.net", network),
+					testAccCheckResourceNetworkTagsCount(network, 2),
+					resource.TestCheckResourceAttrPair("exoscale_network.net", "tags.fp", "exoscale_ssh_keypair.key", "fingerprint"),
+					resource.TestCheckResourceAttrPair("exoscale_network.net", "tags_all.fp", "exoscale_ssh_keypair.key", "fingerprint"),
+					resource.TestCheckResourceAttr("exoscale_network.net", "tags_all.team", "infra"),
+				),
+			},
+			{
+				// The key pair is replaced, the tag value is unknown when planning the update
+				Config: fmt.Sprintf(testAccResourceNetworkConfigInterpolatedTags, "terraform-test-keypair2"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceNetworkExists("exoscale_network.net", network),
+					testAccCheckResourceNetworkTagsCount(network, 2),
+					resource.TestCheckResourceAttrPair("exoscale_network.net", "tags.fp", "exoscale_ssh_keypair.key", "fingerprint"),
+					resource.TestCheckResourceAttrPair("exoscale_network.net", "tags_all.fp", "exoscale_ssh_keypair.key", "fingerprint"),
+					resource.TestCheckResourceAttr("exoscale_network.net", "tags_all.team", "infra"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccResourceNetworkDeletionProtection(t *testing.T) {
+	network := new(egoscale.Network)
+	updated := new(egoscale.Network)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceNetworkDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccResourceNetworkConfigDeletionProtection, defaultExoscaleZone, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceNetworkExists("exoscale_network.net", network),
+					resource.TestCheckResourceAttr("exoscale_network.net", "deletion_protection", "true"),
+				),
+			},
+			{
+				// The protection must be disabled by a separate apply
+				Config:      fmt.Sprintf(testAccResourceNetworkConfigDeletionProtection, testAccZone2, false),
+				ExpectError: regexp.MustCompile(`deletion protection is enabled, changing "zone" would replace the resource`),
+			},
+			{
+				Config:      fmt.Sprintf(testAccResourceNetworkConfigDeletionProtection, defaultExoscaleZone, true),
+				Destroy:     true,
+				ExpectError: regexp.MustCompile("deletion protection is enabled"),
+			},
+			{
+				Config: fmt.Sprintf(testAccResourceNetworkConfigDeletionProtection, defaultExoscaleZone, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckResourceNetworkExists("exoscale_network.net", updated),
+					resource.TestCheckResourceAttr("exoscale_network.net", "deletion_protection", "false"),
+					func(s *terraform.State) error {
+						if !updated.ID.Equal(*network.ID) {
+							return errors.New("expected the network to be updated in place")
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
 func testAccCheckResourceNetworkExists(name string, network *egoscale.Network) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[name]
@@ -78,7 +243,10 @@ func testAccCheckResourceNetworkExists(name string, network *egoscale.Network) r
 			return err
 		}
 
-		client := GetComputeClient(testAccProvider.Meta())
+		client, err := GetComputeClient(testAccProvider.Meta())
+		if err != nil {
+			return err
+		}
 		network.ID = id
 		network.Name = "" // Reset network name to avoid side-effects from previous test steps
 		resp, err := client.Get(network)
@@ -104,6 +272,16 @@ func testAccCheckResourceNetwork(network *egoscale.Network, expectedStartIP net.
 	}
 }
 
+func testAccCheckResourceNetworkTagsCount(network *egoscale.Network, expected int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if len(network.Tags) != expected {
+			return fmt.Errorf("expected %d tags on the network, got %d", expected, len(network.Tags))
+		}
+
+		return nil
+	}
+}
+
 func testAccCheckResourceNetworkAttributes(expected testAttrs) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		for _, rs := range s.RootModule().Resources {
@@ -119,7 +297,10 @@ func testAccCheckResourceNetworkAttributes(expected testAttrs) resource.TestChec
 }
 
 func testAccCheckResourceNetworkDestroy(s *terraform.State) error {
-	client := GetComputeClient(testAccProvider.Meta())
+	client, err := GetComputeClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "exoscale_network" {
@@ -177,3 +358,68 @@ resource "exoscale_network" "net" {
 	defaultExoscaleZone,
 	defaultExoscaleNetworkOffering,
 )
+
+var testAccResourceNetworkConfigDefaultTags = fmt.Sprintf(`
+provider "exoscale" {
+  default_tags {
+    tags = {
+      env = "test"
+      team = "%%s"
+    }
+  }
+}
+
+resource "exoscale_network" "net" {
+  zone = %q
+  network_offering = %q
+  name = "terraform-test-network"
+  display_text = "Terraform Acceptance Test (default tags)"
+
+  tags = {
+    managedby = "terraform"
+    env = "prod"
+  }
+}
+`,
+	defaultExoscaleZone,
+	defaultExoscaleNetworkOffering,
+)
+
+var testAccResourceNetworkConfigInterpolatedTags = fmt.Sprintf(`
+provider "exoscale" {
+  default_tags {
+    tags = {
+      team = "infra"
+    }
+  }
+}
+
+resource "exoscale_ssh_keypair" "key" {
+  name = "%%s"
+}
+
+resource "exoscale_network" "net" {
+  zone = %q
+  network_offering = %q
+  name = "terraform-test-network"
+  display_text = "Terraform Acceptance Test (interpolated tags)"
+
+  tags = {
+    fp = exoscale_ssh_keypair.key.fingerprint
+  }
+}
+`,
+	defaultExoscaleZone,
+	defaultExoscaleNetworkOffering,
+)
+
+var testAccResourceNetworkConfigDeletionProtection = fmt.Sprintf(`
+resource "exoscale_network" "net" {
+  zone = %%q
+  network_offering = %q
+  name = "terraform-test-network"
+  deletion_protection = %%t
+}
+`,
+	defaultExoscaleNetworkOffering,
+)
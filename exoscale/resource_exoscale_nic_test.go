@@ -66,7 +66,10 @@ func testAccCheckResourceNICExists(n string, vm *egoscale.VirtualMachine, nic *e
 			return err
 		}
 
-		client := GetComputeClient(testAccProvider.Meta())
+		client, err := GetComputeClient(testAccProvider.Meta())
+		if err != nil {
+			return err
+		}
 		nic.VirtualMachineID = vm.ID
 		nic.ID = id
 		resp, err := client.Get(nic)
@@ -107,7 +110,10 @@ func testAccCheckResourceNICAttributes(expected testAttrs) resource.TestCheckFun
 }
 
 func testAccCheckResourceNICDestroy(s *terraform.State) error {
-	client := GetComputeClient(testAccProvider.Meta())
+	client, err := GetComputeClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "exoscale_nic" {
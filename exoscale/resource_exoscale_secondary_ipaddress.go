@@ -64,7 +64,10 @@ func resourceSecondaryIPAddressCreate(d *schema.ResourceData, meta interface{})
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	virtualMachineID, err := egoscale.ParseUUID(d.Get("compute_id").(string))
 	if err != nil {
@@ -154,7 +157,10 @@ func getSecondaryIP(d *schema.ResourceData, meta interface{}) (*egoscale.NicSeco
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return nil, err
+	}
 
 	ipAddress := d.Get("ip_address").(string)
 	virtualMachine := d.Get("compute_id").(string)
@@ -294,7 +300,10 @@ func resourceSecondaryIPAddressDelete(d *schema.ResourceData, meta interface{})
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutDelete))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	if err := client.BooleanRequestWithContext(ctx, &egoscale.RemoveIPFromNic{ID: ip.ID}); err != nil {
 		return err
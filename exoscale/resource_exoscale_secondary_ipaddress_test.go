@@ -96,7 +96,10 @@ func testAccCheckResourceSecondaryIPAddressAttributes(expected testAttrs) resour
 }
 
 func testAccCheckResourceSecondaryIPAddressDestroy(s *terraform.State) error {
-	client := GetComputeClient(testAccProvider.Meta())
+	client, err := GetComputeClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "exoscale_secondary_ipaddress" {
@@ -148,7 +151,7 @@ resource "exoscale_ipaddress" "eip" {
   zone = %q
 
   tags = {
-    terraform = "acceptance"
+    test = "acceptance"
   }
 }
 
@@ -56,7 +56,10 @@ func resourceSecurityGroupCreate(d *schema.ResourceData, meta interface{}) error
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	resp, err := client.RequestWithContext(ctx, &egoscale.CreateSecurityGroup{
 		Name:        d.Get("name").(string),
@@ -79,7 +82,10 @@ func resourceSecurityGroupExists(d *schema.ResourceData, meta interface{}) (bool
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return false, err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -105,7 +111,10 @@ func resourceSecurityGroupRead(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -132,7 +141,10 @@ func resourceSecurityGroupDelete(d *schema.ResourceData, meta interface{}) error
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutDelete))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
@@ -154,7 +166,10 @@ func resourceSecurityGroupImport(d *schema.ResourceData, meta interface{}) ([]*s
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return nil, err
+	}
 
 	securityGroup := &egoscale.SecurityGroup{}
 
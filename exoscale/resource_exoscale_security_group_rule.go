@@ -129,7 +129,10 @@ func resourceSecurityGroupRuleCreate(d *schema.ResourceData, meta interface{}) e
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	sg, err := inferSecurityGroup(d)
 	if err != nil {
@@ -233,7 +236,10 @@ func resourceSecurityGroupRuleExists(d *schema.ResourceData, meta interface{}) (
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return false, err
+	}
 
 	sg, err := inferSecurityGroup(d)
 	if err != nil {
@@ -296,7 +302,10 @@ func resourceSecurityGroupRuleRead(d *schema.ResourceData, meta interface{}) err
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	sg, err := inferSecurityGroup(d)
 	if err != nil {
@@ -370,7 +379,10 @@ func resourceSecurityGroupRuleDelete(d *schema.ResourceData, meta interface{}) e
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutDelete))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	id, err := egoscale.ParseUUID(d.Id())
 	if err != nil {
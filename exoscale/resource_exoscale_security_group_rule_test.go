@@ -158,7 +158,10 @@ func testAccCheckResourceSecurityGroupRuleAttributes(expected testAttrs) resourc
 }
 
 func testAccCheckResourceSecurityGroupRuleDestroy(s *terraform.State) error {
-	client := GetComputeClient(testAccProvider.Meta())
+	client, err := GetComputeClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "exoscale_security_group_rule" {
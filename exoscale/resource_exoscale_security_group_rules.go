@@ -119,7 +119,10 @@ func resourceSecurityGroupRulesCreate(d *schema.ResourceData, meta interface{})
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	sg, err := inferSecurityGroup(d)
 	if err != nil {
@@ -213,7 +216,10 @@ func resourceSecurityGroupRulesRead(d *schema.ResourceData, meta interface{}) er
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	sg, err := inferSecurityGroup(d)
 	if err != nil {
@@ -276,7 +282,10 @@ func resourceSecurityGroupRulesUpdate(d *schema.ResourceData, meta interface{})
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	sgID, err := egoscale.ParseUUID(d.Get("security_group_id").(string))
 	if err != nil {
@@ -397,7 +406,10 @@ func resourceSecurityGroupRulesDelete(d *schema.ResourceData, meta interface{})
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutDelete))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	if rules := d.Get("ingress").(*schema.Set); rules.Len() > 0 {
 		for _, r := range rules.List() {
@@ -590,7 +602,7 @@ func ruleToRevoke(rule map[string]interface{}) (map[string]egoscale.RevokeSecuri
 }
 
 // ruleToAuthorize converts a rule (or rules) into a list of authorize requests.
-func ruleToAuthorize(ctx context.Context, client *egoscale.Client, rule map[string]interface{}) ([]egoscale.AuthorizeSecurityGroupIngress, error) {
+func ruleToAuthorize(ctx context.Context, client *computeClient, rule map[string]interface{}) ([]egoscale.AuthorizeSecurityGroupIngress, error) {
 	description := rule["description"].(string)
 	protocol := rule["protocol"].(string)
 
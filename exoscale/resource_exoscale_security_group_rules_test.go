@@ -240,7 +240,10 @@ func testAccCheckSecurityGroupEgressRuleExists(sg *egoscale.SecurityGroup, rule
 }
 
 func testAccCheckResourceSecurityGroupRulesDestroy(s *terraform.State) error {
-	client := GetComputeClient(testAccProvider.Meta())
+	client, err := GetComputeClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "exoscale_security_group_rules" {
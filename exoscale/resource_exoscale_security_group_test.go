@@ -1,8 +1,11 @@
 package exoscale
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"testing"
 
 	"github.com/exoscale/egoscale"
@@ -15,6 +18,47 @@ const (
 	testSecurityGroupDescription = "Terraform Security Group Test"
 )
 
+func init() {
+	resource.AddTestSweepers("exoscale_security_group", &resource.Sweeper{
+		Name:         "exoscale_security_group",
+		Dependencies: []string{"exoscale_compute"},
+		F:            testSweepSecurityGroup,
+	})
+}
+
+func testSweepSecurityGroup(zoneName string) error {
+	config, err := testSweeperConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := GetComputeClient(config)
+	if err != nil {
+		return err
+	}
+
+	sgs, err := client.ListWithContext(ctx, &egoscale.SecurityGroup{})
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, item := range sgs {
+		sg := item.(*egoscale.SecurityGroup)
+		if !strings.HasPrefix(sg.Name, testPrefix) {
+			continue
+		}
+
+		log.Printf("[INFO] sweeping exoscale_security_group %s (ID = %s)", sg.Name, sg.ID)
+		if err := client.DeleteWithContext(ctx, sg); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to delete security group %s: %s", sg.ID, err))
+		}
+	}
+
+	return testSweepErrors(errs)
+}
+
 func TestAccResourceSecurityGroup(t *testing.T) {
 	sg := new(egoscale.SecurityGroup)
 
@@ -66,7 +110,10 @@ func testAccCheckResourceSecurityGroupExists(n string, sg *egoscale.SecurityGrou
 			return err
 		}
 
-		client := GetComputeClient(testAccProvider.Meta())
+		client, err := GetComputeClient(testAccProvider.Meta())
+		if err != nil {
+			return err
+		}
 		resp, err := client.Get(&egoscale.SecurityGroup{
 			ID: id,
 		})
@@ -103,7 +150,10 @@ func testAccCheckResourceSecurityGroupAttributes(expected testAttrs) resource.Te
 }
 
 func testAccCheckResourceSecurityGroupDestroy(s *terraform.State) error {
-	client := GetComputeClient(testAccProvider.Meta())
+	client, err := GetComputeClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "exoscale_security_group" {
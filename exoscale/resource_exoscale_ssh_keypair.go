@@ -61,7 +61,10 @@ func resourceSSHKeypairCreate(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutCreate))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	name := d.Get("name").(string)
 	publicKey, publicKeyOk := d.GetOk("public_key")
@@ -93,13 +96,16 @@ func resourceSSHKeypairExists(d *schema.ResourceData, meta interface{}) (bool, e
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return false, err
+	}
 
 	key := &egoscale.SSHKeyPair{
 		Name: d.Id(),
 	}
 
-	_, err := client.GetWithContext(ctx, key)
+	_, err = client.GetWithContext(ctx, key)
 	if err != nil {
 		e := handleNotFound(d, err)
 		return d.Id() != "", e
@@ -114,7 +120,10 @@ func resourceSSHKeypairRead(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutRead))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	key := &egoscale.SSHKeyPair{Name: d.Id()}
 
@@ -134,7 +143,10 @@ func resourceSSHKeypairDelete(d *schema.ResourceData, meta interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutDelete))
 	defer cancel()
 
-	client := GetComputeClient(meta)
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		return err
+	}
 
 	key := &egoscale.SSHKeyPair{Name: d.Id()}
 
@@ -1,8 +1,11 @@
 package exoscale
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"testing"
 
 	"github.com/exoscale/egoscale"
@@ -16,6 +19,47 @@ const (
 	testSSHKeyName        = "terraform-test-keypair"
 )
 
+func init() {
+	resource.AddTestSweepers("exoscale_ssh_keypair", &resource.Sweeper{
+		Name:         "exoscale_ssh_keypair",
+		Dependencies: []string{"exoscale_compute"},
+		F:            testSweepSSHKeypair,
+	})
+}
+
+func testSweepSSHKeypair(zoneName string) error {
+	config, err := testSweeperConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := GetComputeClient(config)
+	if err != nil {
+		return err
+	}
+
+	keys, err := client.ListWithContext(ctx, &egoscale.SSHKeyPair{})
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, item := range keys {
+		key := item.(*egoscale.SSHKeyPair)
+		if !strings.HasPrefix(key.Name, testPrefix) {
+			continue
+		}
+
+		log.Printf("[INFO] sweeping exoscale_ssh_keypair %s", key.Name)
+		if err := client.DeleteWithContext(ctx, key); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to delete SSH key pair %s: %s", key.Name, err))
+		}
+	}
+
+	return testSweepErrors(errs)
+}
+
 func TestAccResourceSSHKeypair(t *testing.T) {
 	sshkey := new(egoscale.SSHKeyPair)
 
@@ -64,7 +108,10 @@ func testAccCheckResourceSSHKeypairExists(n string, sshkey *egoscale.SSHKeyPair)
 			return errors.New("resource ID not set")
 		}
 
-		client := GetComputeClient(testAccProvider.Meta())
+		client, err := GetComputeClient(testAccProvider.Meta())
+		if err != nil {
+			return err
+		}
 		sshkey.Name = rs.Primary.ID
 		resp, err := client.Get(sshkey)
 		if err != nil {
@@ -100,7 +147,10 @@ func testAccCheckResourceSSHKeypairAttributes(expected testAttrs) resource.TestC
 }
 
 func testAccCheckResourceSSHKeypairDestroy(s *terraform.State) error {
-	client := GetComputeClient(testAccProvider.Meta())
+	client, err := GetComputeClient(testAccProvider.Meta())
+	if err != nil {
+		return err
+	}
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "exoscale_ssh_keypair" {
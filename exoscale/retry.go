@@ -0,0 +1,459 @@
+package exoscale
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/exoscale/egoscale"
+)
+
+// computeClient is the egoscale client given to the compute resources, its
+// requests are retried when failing with a transient error.
+type computeClient struct {
+	*egoscale.Client
+	retrier
+	cache *lookupCache
+}
+
+// dnsClient is the egoscale client given to the DNS resources, its requests
+// are retried when failing with a transient error.
+type dnsClient struct {
+	*egoscale.Client
+	retrier
+}
+
+// retrier sends the requests again, up to maxAttempts times, when they fail
+// with a transient error.
+type retrier struct {
+	maxAttempts   int
+	retryStrategy egoscale.RetryStrategyFunc
+}
+
+// exponentialRetryStrategy doubles the delay on each iteration, from backoff
+// up to eight times its value. It paces the retries of the failed requests,
+// the polling of the async jobs keeps the egoscale strategy.
+func exponentialRetryStrategy(backoff time.Duration) egoscale.RetryStrategyFunc {
+	return func(iteration int64) time.Duration {
+		if iteration > 3 {
+			iteration = 3
+		}
+
+		return backoff << uint(iteration)
+	}
+}
+
+// retryableErrorCodes are the API error codes of transient failures, the
+// request was refused.
+var retryableErrorCodes = map[egoscale.ErrorCode]bool{
+	egoscale.APILimitExceeded:         true,
+	egoscale.ResourceUnavailableError: true,
+	egoscale.ResourceInUseError:       true,
+}
+
+// retryableCSErrorCodes are the CloudStack error codes of transient failures,
+// e.g. a busy resource refusing an async job.
+var retryableCSErrorCodes = map[egoscale.CSErrorCode]bool{
+	egoscale.AgentUnavailableException:    true,
+	egoscale.ConcurrentOperationException: true,
+	egoscale.RequestLimitException:        true,
+	egoscale.ResourceUnavailableException: true,
+	egoscale.StorageUnavailableException:  true,
+}
+
+// isRetryableError tells whether the request failing with err may succeed
+// if sent again. The internal errors, the errors of the load balancers in
+// front of the API and most network errors may happen after the request was
+// processed: only the idempotent requests are sent again, others could create
+// the same resource twice.
+func isRetryableError(err error, idempotent bool) bool {
+	switch e := err.(type) {
+	case *egoscale.ErrorResponse:
+		if e.ErrorCode == egoscale.InternalError {
+			return idempotent
+		}
+		return retryableErrorCodes[e.ErrorCode] || retryableCSErrorCodes[e.CSErrorCode]
+	case net.Error:
+		// A timed out request may have been processed as well
+		return !e.Timeout() && (idempotent || isRequestNotSent(err))
+	}
+
+	// The error pages of the load balancers in front of the API aren't JSON,
+	// the compute and DNS requests word it differently
+	message := err.Error()
+	return idempotent && (strings.Contains(message, "content-type response expected") ||
+		strings.Contains(message, "response content-type expected"))
+}
+
+// isRequestNotSent tells whether the network error happened before the
+// request reached the API, i.e. the connection couldn't be established.
+func isRequestNotSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// idempotentCommandPrefixes are the prefixes of the commands which can be sent
+// twice with the same outcome: the reads and the updates.
+var idempotentCommandPrefixes = []string{"list", "query", "get", "update"}
+
+// isIdempotentCommand tells whether the command, by API name, can be sent again
+func isIdempotentCommand(name string) bool {
+	for _, prefix := range idempotentCommandPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retryResourceID extracts the identifier of the resource targeted by a
+// command or a resource used as a filter, for logging purposes.
+func retryResourceID(v interface{}) string {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return "<new resource>"
+	}
+
+	for _, field := range []string{"ID", "VirtualMachineID", "NicID", "SecurityGroupID", "Name"} {
+		f := value.FieldByName(field)
+		if !f.IsValid() {
+			continue
+		}
+
+		if f.Kind() == reflect.Ptr {
+			if f.IsNil() {
+				continue
+			}
+			f = f.Elem()
+		}
+
+		if id := fmt.Sprint(f.Interface()); id != "" {
+			return id
+		}
+	}
+
+	return "<new resource>"
+}
+
+// retry calls f until it succeeds, fails with a fatal error or the maximum
+// number of attempts is reached.
+func (r *retrier) retry(ctx context.Context, name string, id string, idempotent bool, f func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= r.maxAttempts || !isRetryableError(err, idempotent) {
+			return err
+		}
+
+		wait := r.retryStrategy(int64(attempt - 1))
+		log.Printf("[WARN] %s (ID = %s): attempt %d/%d failed, retrying in %s: %s",
+			name, id, attempt, r.maxAttempts, wait, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// RequestWithContext sends the command, retrying it on transient failures
+func (client *computeClient) RequestWithContext(ctx context.Context, command egoscale.Command) (interface{}, error) {
+	var resp interface{}
+	name := client.APIName(command)
+	err := client.retry(ctx, name, retryResourceID(command), isIdempotentCommand(name), func() error {
+		var err error
+		resp, err = client.Client.RequestWithContext(ctx, command)
+		return err
+	})
+
+	return resp, err
+}
+
+// RequestWithEmptyParamsContext sends the async command along with the given
+// parameters set to an empty value, which the egoscale serialization omits,
+// e.g. to clear a setting. It retries it on transient failures.
+func (client *computeClient) RequestWithEmptyParamsContext(ctx context.Context, command egoscale.AsyncCommand, empty ...string) (interface{}, error) {
+	var resp interface{}
+	name := client.APIName(command)
+	err := client.retry(ctx, name, retryResourceID(command), isIdempotentCommand(name), func() error {
+		var err error
+		resp, err = client.requestWithEmptyParams(ctx, command, empty)
+		return err
+	})
+
+	return resp, err
+}
+
+// requestWithEmptyParams signs and sends the command as egoscale does, then
+// waits for the result of its async job
+func (client *computeClient) requestWithEmptyParams(ctx context.Context, command egoscale.AsyncCommand, empty []string) (interface{}, error) {
+	params, err := client.Payload(command)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range empty {
+		params.Set(key, "")
+	}
+
+	signature, err := client.Sign(params)
+	if err != nil {
+		return nil, err
+	}
+	params.Add("signature", signature)
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", client.Endpoint, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("User-Agent", fmt.Sprintf("exoscale/egoscale (%v)", egoscale.Version))
+
+	resp, err := client.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if contentType := resp.Header.Get("content-type"); !strings.Contains(contentType, "application/json") {
+		return nil, fmt.Errorf(`response content-type expected to be "application/json", got %q`, contentType)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, err
+	}
+
+	response, ok := m[strings.ToLower(client.APIName(command))+"response"]
+	if !ok {
+		response, ok = m["errorresponse"]
+	}
+	if !ok {
+		return nil, fmt.Errorf("malformed JSON response %d: %s", resp.StatusCode, body)
+	}
+
+	if resp.StatusCode >= 400 {
+		errorResponse := new(egoscale.ErrorResponse)
+		if err := json.Unmarshal(response, errorResponse); err != nil {
+			return nil, fmt.Errorf("%d %s", resp.StatusCode, body)
+		}
+		return nil, errorResponse
+	}
+
+	job := new(egoscale.AsyncJobResult)
+	if err := json.Unmarshal(response, job); err != nil {
+		return nil, err
+	}
+
+	for iteration := 0; job.JobStatus == egoscale.Pending; iteration++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(client.RetryStrategy(int64(iteration))):
+		}
+
+		resp, err := client.Client.RequestWithContext(ctx, &egoscale.QueryAsyncJobResult{JobID: job.JobID})
+		if err != nil {
+			return nil, err
+		}
+		job = resp.(*egoscale.AsyncJobResult)
+	}
+
+	result := command.AsyncResponse()
+	if err := job.Result(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// BooleanRequestWithContext sends the command, retrying it on transient failures
+func (client *computeClient) BooleanRequestWithContext(ctx context.Context, command egoscale.Command) error {
+	name := client.APIName(command)
+	return client.retry(ctx, name, retryResourceID(command), isIdempotentCommand(name), func() error {
+		return client.Client.BooleanRequestWithContext(ctx, command)
+	})
+}
+
+// GetWithContext fetches the resource, retrying on transient failures
+func (client *computeClient) GetWithContext(ctx context.Context, g egoscale.Listable) (interface{}, error) {
+	var resp interface{}
+	err := client.retry(ctx, fmt.Sprintf("get %T", g), retryResourceID(g), true, func() error {
+		var err error
+		resp, err = client.Client.GetWithContext(ctx, g)
+		return err
+	})
+
+	return resp, err
+}
+
+// ListWithContext lists the resources, retrying on transient failures
+func (client *computeClient) ListWithContext(ctx context.Context, g egoscale.Listable) ([]interface{}, error) {
+	var resp []interface{}
+	err := client.retry(ctx, fmt.Sprintf("list %T", g), retryResourceID(g), true, func() error {
+		var err error
+		resp, err = client.Client.ListWithContext(ctx, g)
+		return err
+	})
+
+	return resp, err
+}
+
+// DeleteWithContext removes the resource, retrying on transient failures
+func (client *computeClient) DeleteWithContext(ctx context.Context, g egoscale.Deletable) error {
+	return client.retry(ctx, fmt.Sprintf("delete %T", g), retryResourceID(g), false, func() error {
+		return client.Client.DeleteWithContext(ctx, g)
+	})
+}
+
+// PaginateWithContext walks the pages of the resources, retrying on transient
+// failures as long as none was given to the callback.
+func (client *computeClient) PaginateWithContext(ctx context.Context, g egoscale.Listable, callback egoscale.IterateItemFunc) {
+	err := client.retry(ctx, fmt.Sprintf("list %T", g), retryResourceID(g), true, func() error {
+		var err error
+		started := false
+		client.Client.PaginateWithContext(ctx, g, func(v interface{}, e error) bool {
+			if e != nil && !started {
+				err = e
+				return false
+			}
+
+			started = true
+			return callback(v, e)
+		})
+		return err
+	})
+
+	if err != nil {
+		callback(nil, err)
+	}
+}
+
+// CreateDomain creates the DNS domain, retrying on transient failures
+func (client *dnsClient) CreateDomain(ctx context.Context, name string) (*egoscale.DNSDomain, error) {
+	var domain *egoscale.DNSDomain
+	err := client.retry(ctx, "create domain", name, false, func() error {
+		var err error
+		domain, err = client.Client.CreateDomain(ctx, name)
+		return err
+	})
+
+	return domain, err
+}
+
+// GetDomain fetches the DNS domain, retrying on transient failures
+func (client *dnsClient) GetDomain(ctx context.Context, name string) (*egoscale.DNSDomain, error) {
+	var domain *egoscale.DNSDomain
+	err := client.retry(ctx, "get domain", name, true, func() error {
+		var err error
+		domain, err = client.Client.GetDomain(ctx, name)
+		return err
+	})
+
+	return domain, err
+}
+
+// GetDomains lists the DNS domains, retrying on transient failures
+func (client *dnsClient) GetDomains(ctx context.Context) ([]egoscale.DNSDomain, error) {
+	var domains []egoscale.DNSDomain
+	err := client.retry(ctx, "list domains", "<all>", true, func() error {
+		var err error
+		domains, err = client.Client.GetDomains(ctx)
+		return err
+	})
+
+	return domains, err
+}
+
+// DeleteDomain removes the DNS domain, retrying on transient failures
+func (client *dnsClient) DeleteDomain(ctx context.Context, name string) error {
+	return client.retry(ctx, "delete domain", name, false, func() error {
+		return client.Client.DeleteDomain(ctx, name)
+	})
+}
+
+// GetRecord fetches the DNS record, retrying on transient failures
+func (client *dnsClient) GetRecord(ctx context.Context, domain string, recordID int64) (*egoscale.DNSRecord, error) {
+	var record *egoscale.DNSRecord
+	err := client.retry(ctx, "get record", strconv.FormatInt(recordID, 10), true, func() error {
+		var err error
+		record, err = client.Client.GetRecord(ctx, domain, recordID)
+		return err
+	})
+
+	return record, err
+}
+
+// GetRecords lists the records of the DNS domain, retrying on transient failures
+func (client *dnsClient) GetRecords(ctx context.Context, domain string) ([]egoscale.DNSRecord, error) {
+	var records []egoscale.DNSRecord
+	err := client.retry(ctx, "list records", domain, true, func() error {
+		var err error
+		records, err = client.Client.GetRecords(ctx, domain)
+		return err
+	})
+
+	return records, err
+}
+
+// GetRecordsWithFilters lists the matching records of the DNS domain,
+// retrying on transient failures
+func (client *dnsClient) GetRecordsWithFilters(ctx context.Context, domain, name, recordType string) ([]egoscale.DNSRecord, error) {
+	var records []egoscale.DNSRecord
+	err := client.retry(ctx, "list records", domain, true, func() error {
+		var err error
+		records, err = client.Client.GetRecordsWithFilters(ctx, domain, name, recordType)
+		return err
+	})
+
+	return records, err
+}
+
+// CreateRecord creates the DNS record, retrying on transient failures
+func (client *dnsClient) CreateRecord(ctx context.Context, name string, rec egoscale.DNSRecord) (*egoscale.DNSRecord, error) {
+	var record *egoscale.DNSRecord
+	err := client.retry(ctx, "create record", "<new resource>", false, func() error {
+		var err error
+		record, err = client.Client.CreateRecord(ctx, name, rec)
+		return err
+	})
+
+	return record, err
+}
+
+// UpdateRecord updates the DNS record, retrying on transient failures
+func (client *dnsClient) UpdateRecord(ctx context.Context, name string, rec egoscale.UpdateDNSRecord) (*egoscale.DNSRecord, error) {
+	var record *egoscale.DNSRecord
+	err := client.retry(ctx, "update record", strconv.FormatInt(rec.ID, 10), true, func() error {
+		var err error
+		record, err = client.Client.UpdateRecord(ctx, name, rec)
+		return err
+	})
+
+	return record, err
+}
+
+// DeleteRecord removes the DNS record, retrying on transient failures
+func (client *dnsClient) DeleteRecord(ctx context.Context, name string, recordID int64) error {
+	return client.retry(ctx, "delete record", strconv.FormatInt(recordID, 10), false, func() error {
+		return client.Client.DeleteRecord(ctx, name, recordID)
+	})
+}
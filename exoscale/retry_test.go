@@ -0,0 +1,187 @@
+package exoscale
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/exoscale/egoscale"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	for _, tt := range []struct {
+		err        error
+		idempotent bool
+		retryable  bool
+	}{
+		{&egoscale.ErrorResponse{ErrorCode: egoscale.APILimitExceeded}, false, true},
+		{&egoscale.ErrorResponse{ErrorCode: egoscale.InternalError}, true, true},
+		{&egoscale.ErrorResponse{ErrorCode: egoscale.InternalError}, false, false},
+		{&egoscale.ErrorResponse{ErrorCode: egoscale.ParamError, CSErrorCode: egoscale.ConcurrentOperationException}, false, true},
+		{&egoscale.ErrorResponse{ErrorCode: egoscale.ParamError, CSErrorCode: egoscale.InvalidParameterValueException}, true, false},
+		{&egoscale.ErrorResponse{ErrorCode: egoscale.Unauthorized}, true, false},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, false, true},
+		{&url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Err: errors.New("no such host")}}, false, true},
+		{&url.Error{Op: "Post", Err: &net.OpError{Op: "write", Err: syscall.ECONNREFUSED}}, false, true},
+		{&url.Error{Op: "Post", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, true, true},
+		{&url.Error{Op: "Post", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, false, false},
+		{errors.New(`response content-type expected to be "application/json", got "text/html"`), true, true},
+		{errors.New(`response content-type expected to be "application/json", got "text/html"`), false, false},
+		{errors.New(`body content-type response expected "application/json", got "text/html"`), true, true},
+		{errors.New(`body content-type response expected "application/json", got "text/html"`), false, false},
+		{errors.New("more than one element found"), true, false},
+	} {
+		if got := isRetryableError(tt.err, tt.idempotent); got != tt.retryable {
+			t.Errorf("isRetryableError(%#v, %t): expected %t, got %t", tt.err, tt.idempotent, tt.retryable, got)
+		}
+	}
+}
+
+func TestIsIdempotentCommand(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"listZones":            true,
+		"queryAsyncJobResult":  true,
+		"getVMPassword":        true,
+		"updateIpAddress":      true,
+		"deployVirtualMachine": false,
+		"associateIpAddress":   false,
+		"deleteSecurityGroup":  false,
+	} {
+		if got := isIdempotentCommand(name); got != expected {
+			t.Errorf("isIdempotentCommand(%q): expected %t, got %t", name, expected, got)
+		}
+	}
+}
+
+func TestExponentialRetryStrategy(t *testing.T) {
+	strategy := exponentialRetryStrategy(time.Second)
+
+	for iteration, expected := range []time.Duration{1, 2, 4, 8, 8} {
+		if got := strategy(int64(iteration)); got != expected*time.Second {
+			t.Errorf("iteration %d: expected %s, got %s", iteration, expected*time.Second, got)
+		}
+	}
+}
+
+func TestComputeClientRetry(t *testing.T) {
+	api, meta := newMockComputeConfig(3)
+	defer api.Close()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api.failNext("listZones", mockComputeError(egoscale.InternalError, "oops"))
+	api.failNext("listZones", mockComputeError(egoscale.APILimitExceeded, "slow down"))
+	if _, err := getZoneByName(context.TODO(), client, defaultExoscaleZone); err != nil {
+		t.Errorf("expected the request to succeed on the third attempt, got %s", err)
+	}
+
+	api.failNext("associateIpAddress", &egoscale.ErrorResponse{
+		ErrorCode:   egoscale.ParamError,
+		CSErrorCode: egoscale.ConcurrentOperationException,
+		ErrorText:   "resource busy",
+	})
+	resp, err := client.RequestWithContext(context.TODO(), &egoscale.AssociateIPAddress{ZoneID: mockComputeZoneID})
+	if err != nil {
+		t.Fatalf("expected the async job to succeed on the second attempt, got %s", err)
+	}
+	if len(api.ipAddresses) != 1 || !api.ipAddresses[0].ID.Equal(*resp.(*egoscale.IPAddress).ID) {
+		t.Errorf("expected a single IP address to be allocated, got %d", len(api.ipAddresses))
+	}
+}
+
+func TestComputeClientRetryFatal(t *testing.T) {
+	api, meta := newMockComputeConfig(3)
+	defer api.Close()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api.failNext("listZones", mockComputeError(egoscale.ParamError, "invalid"))
+	if _, err := getZoneByName(context.TODO(), client, defaultExoscaleZone); err == nil {
+		t.Error("expected a fatal error not to be retried")
+	}
+}
+
+func TestComputeClientRetryExhausted(t *testing.T) {
+	api, meta := newMockComputeConfig(2)
+	defer api.Close()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		api.failNext("deleteSecurityGroup", mockComputeError(egoscale.ResourceInUseError, "busy"))
+	}
+
+	err = client.BooleanRequestWithContext(context.TODO(), &egoscale.DeleteSecurityGroup{Name: "default"})
+	if e, ok := err.(*egoscale.ErrorResponse); !ok || e.ErrorCode != egoscale.ResourceInUseError {
+		t.Errorf("expected the last error after 2 attempts, got %v", err)
+	}
+	if n := api.callCount("deleteSecurityGroup"); n != 2 {
+		t.Errorf("expected 2 attempts, got %d", n)
+	}
+}
+
+func TestComputeClientRetryNotIdempotent(t *testing.T) {
+	api, meta := newMockComputeConfig(3)
+	defer api.Close()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The IP address may have been allocated despite the error
+	api.failNext("associateIpAddress", mockComputeError(egoscale.InternalError, "oops"))
+	_, err = client.RequestWithContext(context.TODO(), &egoscale.AssociateIPAddress{ZoneID: mockComputeZoneID})
+	if e, ok := err.(*egoscale.ErrorResponse); !ok || e.ErrorCode != egoscale.InternalError {
+		t.Errorf("expected the internal error not to be retried, got %v", err)
+	}
+	if n := api.callCount("associateIpAddress"); n != 1 {
+		t.Errorf("expected a single attempt, got %d", n)
+	}
+}
+
+func TestComputeClientRetryPaginate(t *testing.T) {
+	api, meta := newMockComputeConfig(3)
+	defer api.Close()
+
+	client, err := GetComputeClient(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api.failNext("listZones", mockComputeError(egoscale.InternalError, "oops"))
+
+	var zones []interface{}
+	client.PaginateWithContext(context.TODO(), &egoscale.Zone{}, func(v interface{}, e error) bool {
+		if e != nil {
+			err = e
+			return false
+		}
+
+		zones = append(zones, v)
+		return true
+	})
+
+	if err != nil {
+		t.Fatalf("expected the listing to succeed on the second attempt, got %s", err)
+	}
+	if len(zones) == 0 {
+		t.Error("expected the zones to be listed")
+	}
+	if n := api.callCount("listZones"); n != 2 {
+		t.Errorf("expected 2 attempts, got %d", n)
+	}
+}
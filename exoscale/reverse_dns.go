@@ -0,0 +1,25 @@
+package exoscale
+
+import (
+	"strings"
+
+	"github.com/exoscale/egoscale"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// getReverseDNS returns the domain name of the IPv4 PTR record, without the
+// trailing dot
+func getReverseDNS(records []egoscale.ReverseDNS) string {
+	for _, record := range records {
+		if record.IPAddress != nil && record.DomainName != "" {
+			return strings.TrimSuffix(record.DomainName, ".")
+		}
+	}
+
+	return ""
+}
+
+// suppressReverseDNSDiff ignores the trailing dot of the domain names
+func suppressReverseDNSDiff(k, old, new string, d *schema.ResourceData) bool {
+	return strings.TrimSuffix(old, ".") == strings.TrimSuffix(new, ".")
+}
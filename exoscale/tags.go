@@ -1,11 +1,14 @@
 package exoscale
 
 import (
+	"reflect"
+
 	"github.com/exoscale/egoscale"
 	"github.com/hashicorp/terraform/helper/schema"
 )
 
-// addTags adds the tags structure to the schema at the given key
+// addTags adds the tags structure to the schema at the given key, along with
+// the computed key_all one holding the tags merged with the provider defaults
 func addTags(s map[string]*schema.Schema, key string) {
 	s[key] = &schema.Schema{
 		Type:     schema.TypeMap,
@@ -16,45 +19,129 @@ func addTags(s map[string]*schema.Schema, key string) {
 		},
 		Description: "Map of tags (key: value)",
 	}
+	s[key+"_all"] = &schema.Schema{
+		Type:     schema.TypeMap,
+		Computed: true,
+		Elem: &schema.Schema{
+			Type: schema.TypeString,
+		},
+		Description: "Map of tags (key: value), including the provider default_tags",
+	}
+}
+
+// mergeTags returns the provider default tags overridden by the given ones
+func mergeTags(defaultTags map[string]string, tags map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(defaultTags)+len(tags))
+	for k, v := range defaultTags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+
+	return merged
 }
 
-// createTags create the tags for the given resource (provide a resource type)
-func createTags(d *schema.ResourceData, key, resourceType string) (egoscale.Command, error) {
-	if t, ok := d.GetOk(key); ok {
-		m := t.(map[string]interface{})
-		tags := make([]egoscale.ResourceTag, 0, len(m))
-		for k, v := range m {
-			tags = append(tags, egoscale.ResourceTag{
-				Key:   k,
-				Value: v.(string),
-			})
+// customizeDiffTags plans the key_all attribute. The planned tags may hold
+// unknown values, they aren't read: key_all is computed when they change,
+// otherwise it follows the provider default tags.
+func customizeDiffTags(key string) schema.CustomizeDiffFunc {
+	return func(d *schema.ResourceDiff, meta interface{}) error {
+		all := key + "_all"
+
+		if len(d.GetChangedKeysPrefix(key+".")) > 0 {
+			return d.SetNewComputed(all)
 		}
 
-		id, err := egoscale.ParseUUID(d.Id())
-		if err != nil {
-			return nil, err
+		// The tags are unchanged, their previous value is the state one
+		tags, _ := d.GetChange(key)
+		current, _ := tags.(map[string]interface{})
+
+		merged := mergeTags(meta.(*BaseConfig).defaultTags, current)
+		if previous, _ := d.Get(all).(map[string]interface{}); reflect.DeepEqual(merged, previous) {
+			return nil
+		}
+
+		return d.SetNew(all, merged)
+	}
+}
+
+// setTags stores the tags of the resource, the provider default tags not
+// already present in the state are only kept in key_all
+func setTags(d *schema.ResourceData, key string, resourceTags []egoscale.ResourceTag, meta interface{}) error {
+	defaultTags := meta.(*BaseConfig).defaultTags
+	current := d.Get(key).(map[string]interface{})
+
+	tags := make(map[string]interface{})
+	all := make(map[string]interface{})
+	for _, tag := range resourceTags {
+		all[tag.Key] = tag.Value
+
+		if v, ok := defaultTags[tag.Key]; ok && v == tag.Value {
+			if _, ok := current[tag.Key]; !ok {
+				continue
+			}
 		}
+		tags[tag.Key] = tag.Value
+	}
+
+	if err := d.Set(key, tags); err != nil {
+		return err
+	}
 
-		return &egoscale.CreateTags{
-			ResourceIDs:  []egoscale.UUID{*id},
-			ResourceType: resourceType,
-			Tags:         tags,
-		}, nil
+	return d.Set(key+"_all", all)
+}
+
+// createTags create the tags for the given resource (provide a resource type),
+// along with the provider default tags
+func createTags(d *schema.ResourceData, key, resourceType string, meta interface{}) (egoscale.Command, error) {
+	m := mergeTags(meta.(*BaseConfig).defaultTags, d.Get(key).(map[string]interface{}))
+	if len(m) == 0 {
+		return nil, nil
 	}
 
-	return nil, nil
+	tags := make([]egoscale.ResourceTag, 0, len(m))
+	for k, v := range m {
+		tags = append(tags, egoscale.ResourceTag{
+			Key:   k,
+			Value: v.(string),
+		})
+	}
+
+	id, err := egoscale.ParseUUID(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	return &egoscale.CreateTags{
+		ResourceIDs:  []egoscale.UUID{*id},
+		ResourceType: resourceType,
+		Tags:         tags,
+	}, nil
 }
 
 // updateTags create the commands to delete / create the tags for a resource
-func updateTags(d *schema.ResourceData, key, resourceType string) ([]egoscale.Command, error) {
+func updateTags(d *schema.ResourceData, key, resourceType string, meta interface{}) ([]egoscale.Command, error) {
+	return updateTagsChanges(d, d, key, resourceType, meta)
+}
+
+// updateTagsChanges create the commands to delete / create the tags for a
+// resource, from the given changes. The tags of the resource, key_all, are
+// replaced by the configured ones merged with the provider default tags.
+func updateTagsChanges(d *schema.ResourceData, changes resourceChanges, key, resourceType string, meta interface{}) ([]egoscale.Command, error) {
 	requests := make([]egoscale.Command, 0)
 
-	if d.HasChange(key) {
+	all := key + "_all"
+	if changes.HasChange(key) || changes.HasChange(all) {
 		d.SetPartial(key)
-		o, n := d.GetChange(key)
+		d.SetPartial(all)
+		o, _ := changes.GetChange(all)
 
-		oldTags := o.(map[string]interface{})
-		newTags := n.(map[string]interface{})
+		oldTags := make(map[string]interface{})
+		for k, v := range o.(map[string]interface{}) {
+			oldTags[k] = v
+		}
+		newTags := mergeTags(meta.(*BaseConfig).defaultTags, d.Get(key).(map[string]interface{}))
 
 		// Remove the intersection between the two sets of tag
 		for k, v := range oldTags {
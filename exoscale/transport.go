@@ -0,0 +1,84 @@
+package exoscale
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// limitedTransport is the HTTP transport shared by all the API clients of a
+// provider instance. It caps the number of requests in flight as well as the
+// rate at which they are sent, whatever the Terraform parallelism.
+type limitedTransport struct {
+	transport http.RoundTripper
+	slots     chan struct{}
+
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newLimitedTransport wraps the transport, a zero value disables the
+// corresponding limit.
+func newLimitedTransport(transport http.RoundTripper, maxConcurrentRequests int, requestsPerSecond float64) *limitedTransport {
+	t := &limitedTransport{
+		transport: transport,
+	}
+
+	if maxConcurrentRequests > 0 {
+		t.slots = make(chan struct{}, maxConcurrentRequests)
+	}
+
+	if requestsPerSecond > 0 {
+		t.interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+
+	return t
+}
+
+// RoundTrip waits for a free slot and its turn before sending the request
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+			defer func() { <-t.slots }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if wait := t.reserve(); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return t.transport.RoundTrip(req)
+}
+
+// reserve books the next sending time and returns how long to wait for it.
+func (t *limitedTransport) reserve() time.Duration {
+	if t.interval == 0 {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+
+	wait := t.next.Sub(now)
+	t.next = t.next.Add(t.interval)
+
+	return wait
+}
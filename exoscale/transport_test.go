@@ -0,0 +1,118 @@
+package exoscale
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimitedTransportConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newLimitedTransport(http.DefaultTransport, 2, 0)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close() // nolint: errcheck
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight != 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", maxInFlight)
+	}
+}
+
+func TestLimitedTransportRate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newLimitedTransport(http.DefaultTransport, 0, 50)}
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close() // nolint: errcheck
+	}
+
+	// The first request goes right away, the five others wait 20ms each
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected 6 requests at 50 requests per second to take at least 100ms, took %s", elapsed)
+	}
+}
+
+func TestSharedClients(t *testing.T) {
+	meta := &BaseConfig{
+		key:             "EXOtest",
+		secret:          "secret",
+		timeout:         defaultTimeout,
+		computeEndpoint: defaultComputeEndpoint,
+		dnsEndpoint:     defaultDNSEndpoint,
+	}
+
+	var wg sync.WaitGroup
+	clients := make([]*computeClient, 10)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client, err := GetComputeClient(meta)
+			if err != nil {
+				t.Error(err)
+			}
+			clients[i] = client
+		}(i)
+	}
+	wg.Wait()
+
+	for _, client := range clients {
+		if client != clients[0] {
+			t.Fatal("expected a single compute client per provider")
+		}
+	}
+
+	dns1, err := GetDNSClient(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dns2, err := GetDNSClient(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dns1 != dns2 {
+		t.Error("expected a single DNS client per provider")
+	}
+
+	transport := meta.transport
+	if transport == nil || dns1 == nil || meta.transport != transport {
+		t.Error("expected the compute and DNS clients to share their transport")
+	}
+}
@@ -141,3 +141,56 @@ func ValidatePortRange(i interface{}, k string) (s []string, es []error) {
 
 	return
 }
+
+// ValidateFloatAtLeast validates that the given field is a float not lower than min
+func ValidateFloatAtLeast(min float64) schema.SchemaValidateFunc {
+	return func(i interface{}, k string) (s []string, es []error) {
+		value, ok := i.(float64)
+		if !ok {
+			es = append(es, fmt.Errorf("expected type of %s to be float", k))
+			return
+		}
+
+		if value < min {
+			es = append(es, fmt.Errorf("expected %s to be at least %v, got %v", k, min, value))
+		}
+
+		return
+	}
+}
+
+var fqdnLabelRegexp = regexp.MustCompile(`^(?i:[a-z0-9]|[a-z0-9][a-z0-9-]{0,61}[a-z0-9])$`)
+
+// ValidateFQDN validates that the given field is a fully qualified domain name
+func ValidateFQDN(i interface{}, k string) (s []string, es []error) {
+	value, ok := i.(string)
+	if !ok {
+		es = append(es, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+
+	name := strings.TrimSuffix(value, ".")
+	if len(name) > 253 {
+		es = append(es, fmt.Errorf("expected %s to be at most 253 characters long, got %d", k, len(name)))
+		return
+	}
+
+	labels := strings.Split(name, ".")
+	if len(labels) < 2 {
+		es = append(es, fmt.Errorf("expected %s to be a fully qualified domain name, got %q", k, value))
+		return
+	}
+
+	for _, label := range labels {
+		if !fqdnLabelRegexp.MatchString(label) {
+			es = append(es, fmt.Errorf("expected %s to be a fully qualified domain name, %q is not a valid label", k, label))
+			return
+		}
+	}
+
+	if _, err := strconv.Atoi(labels[len(labels)-1]); err == nil {
+		es = append(es, fmt.Errorf("expected %s to have a valid TLD, got %q", k, value))
+	}
+
+	return
+}
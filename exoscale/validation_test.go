@@ -1,6 +1,7 @@
 package exoscale
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -144,3 +145,52 @@ func TestValidatePortRangeKo(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateFloatAtLeastOk(t *testing.T) {
+	for _, value := range []float64{0, 0.5, 2} {
+		_, errs := ValidateFloatAtLeast(0)(value, "test_property")
+		if len(errs) != 0 {
+			t.Errorf("no errors were expected %v %v", value, errs)
+		}
+	}
+}
+
+func TestValidateFloatAtLeastKo(t *testing.T) {
+	for _, value := range []interface{}{-0.5, -2.0, "1"} {
+		_, errs := ValidateFloatAtLeast(0)(value, "test_property")
+		if len(errs) == 0 {
+			t.Errorf("an error was expected, %v", value)
+		}
+	}
+}
+
+func TestValidateFQDNOk(t *testing.T) {
+	for _, name := range []string{
+		"example.net",
+		"mail.example.net.",
+		"x-1.eu-west.example.co.uk",
+	} {
+		_, errs := ValidateFQDN(name, "test_property")
+		if len(errs) != 0 {
+			t.Errorf("no errors were expected %q %v", name, errs)
+		}
+	}
+}
+
+func TestValidateFQDNKo(t *testing.T) {
+	for _, name := range []interface{}{
+		15,
+		"",
+		"localhost",
+		"mail..example.net",
+		"-mail.example.net",
+		"mail_1.example.net",
+		"10.0.0.1",
+		strings.Repeat("a", 64) + ".example.net",
+	} {
+		_, errs := ValidateFQDN(name, "test_property")
+		if len(errs) == 0 {
+			t.Errorf("an error was expected, %v", name)
+		}
+	}
+}